@@ -1,15 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/school-system/backend/internal/config"
 	"github.com/school-system/backend/internal/database"
 	"github.com/school-system/backend/internal/handlers"
+	"github.com/school-system/backend/internal/jobs"
 	"github.com/school-system/backend/internal/middleware"
 	"github.com/school-system/backend/internal/models"
 	"github.com/school-system/backend/internal/services"
@@ -73,7 +79,7 @@ func main() {
 	})
 
 	// Static files
-	r.Static("/logos", "./public/logos")
+	r.Static("/logos", cfg.Storage.LogoDir())
 
 	// Health check - simple endpoint that doesn't require DB
 	r.GET("/health", func(c *gin.Context) {
@@ -112,18 +118,40 @@ func main() {
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Services
-	authService := services.NewAuthService(db, cfg)
+	passwordHasher := services.NewPasswordHasher(cfg.Argon2)
+	authService := services.NewAuthService(db, cfg, passwordHasher)
+
+	// Background jobs
+	jobRunner := jobs.NewRunner(db, 5*time.Second)
+	jobRunner.Register(services.JobTypeGenerateReportCards, services.NewReportCardService(db, cfg.Storage).HandleClassJob)
+	jobRunner.Start()
+
+	// Audit entries are linked into the hash chain by one chainer, apart
+	// from the writes they record
+	chainerCtx, stopChainer := context.WithCancel(context.Background())
+	defer stopChainer()
+	go services.NewAuditService(db).RunChainer(chainerCtx, 5*time.Second)
 
 	// Handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	userHandler := handlers.NewUserHandler(db, authService)
-	schoolHandler := handlers.NewSchoolHandler(db)
+	schoolHandler := handlers.NewSchoolHandler(db, passwordHasher)
 	classHandler := handlers.NewClassHandler(db)
 	studentHandler := handlers.NewStudentHandler(db)
 	subjectHandler := handlers.NewSubjectHandler(db)
 	resultHandler := handlers.NewResultHandler(db)
-	uploadHandler := handlers.NewUploadHandler(db)
+	uploadHandler := handlers.NewUploadHandler(db, cfg.Storage)
 	auditHandler := handlers.NewAuditHandler(db)
+	assessmentHandler := handlers.NewAssessmentHandler(db)
+	gradingRuleHandler := handlers.NewGradingRuleHandler(db)
+	reportCardHandler := handlers.NewReportCardHandler(db, cfg.Storage)
+	jobHandler := handlers.NewJobHandler(db, cfg.Storage)
+	termLockHandler := handlers.NewTermLockHandler(db)
+	calendarHandler := handlers.NewAcademicCalendarHandler(db)
+	promotionHandler := handlers.NewPromotionHandler(db)
+	enrollmentHandler := handlers.NewEnrollmentHandler(db)
+	assignmentHandler := handlers.NewTeacherAssignmentHandler(db)
+	schoolUserHandler := handlers.NewSchoolUserHandler(db, passwordHasher)
 
 	// Routes
 	v1 := r.Group("/api/v1")
@@ -133,6 +161,7 @@ func main() {
 			auth.POST("/login", authHandler.Login)
 			auth.POST("/refresh", authHandler.Refresh)
 			auth.POST("/logout", authHandler.Logout)
+			auth.POST("/change-password", middleware.PasswordChangeMiddleware(authService), authHandler.ChangePassword)
 		}
 
 		// Protected routes
@@ -163,6 +192,7 @@ func main() {
 
 				// Audit logs
 				sysAdmin.GET("/audit/recent", auditHandler.GetRecentActivity)
+				sysAdmin.GET("/audit/verify", auditHandler.VerifyChain)
 
 				// Migration and seeding endpoints
 				sysAdmin.POST("/migrate", func(c *gin.Context) {
@@ -189,10 +219,36 @@ func main() {
 			schoolAdmin.Use(middleware.RequireSchoolAdmin())
 			{
 				schoolAdmin.POST("/students", studentHandler.Create)
+				schoolAdmin.POST("/students/import", studentHandler.Import)
 				schoolAdmin.PUT("/students/:id", studentHandler.Update)
 				schoolAdmin.DELETE("/students/:id", studentHandler.Delete)
 				// Note: Subject creation/modification removed - only standard subjects allowed
 				schoolAdmin.DELETE("/results/:id", resultHandler.Delete)
+				schoolAdmin.DELETE("/assessments/:id", assessmentHandler.Delete)
+				schoolAdmin.PUT("/grading-rules/:level", gradingRuleHandler.Update)
+				schoolAdmin.POST("/term-locks", termLockHandler.Lock)
+				schoolAdmin.POST("/academic-years", calendarHandler.CreateYear)
+				schoolAdmin.POST("/promotions/preview", promotionHandler.Preview)
+				schoolAdmin.POST("/promotions/commit", promotionHandler.Commit)
+				schoolAdmin.POST("/enrollments/carry-over", enrollmentHandler.CarryOver)
+				schoolAdmin.POST("/classes/streams", classHandler.AddStream)
+				schoolAdmin.POST("/teacher-assignments", assignmentHandler.Create)
+				schoolAdmin.DELETE("/teacher-assignments/:id", assignmentHandler.Delete)
+
+				// Audit trail, scoped to the admin's own school
+				schoolAdmin.GET("/audit", auditHandler.Search)
+				schoolAdmin.GET("/students/:id/history", auditHandler.StudentHistory)
+				schoolAdmin.GET("/results/:id/history", auditHandler.ResultHistory)
+
+				// Staff management, scoped to the admin's own school
+				schoolAdmin.GET("/school-users", schoolUserHandler.GetSchoolUsers)
+				schoolAdmin.POST("/school-users/teachers", schoolUserHandler.CreateTeacher)
+				schoolAdmin.POST("/school-users/assign-class", schoolUserHandler.AssignTeacherToClass)
+				schoolAdmin.PUT("/school-users/:id/role", schoolUserHandler.UpdateUserRole)
+				schoolAdmin.POST("/school-users/:id/deactivate", schoolUserHandler.Deactivate)
+				schoolAdmin.POST("/school-users/:id/reactivate", schoolUserHandler.Reactivate)
+				schoolAdmin.POST("/school-users/:id/reset-password", schoolUserHandler.ResetPassword)
+				schoolAdmin.POST("/term-locks/:id/unlock", termLockHandler.Unlock)
 			}
 
 			// Teacher routes (all authenticated users)
@@ -202,12 +258,41 @@ func main() {
 			protected.GET("/classes/levels", classHandler.GetLevels)
 			protected.GET("/classes/:id", classHandler.Get)
 			protected.GET("/classes/:id/students", classHandler.GetStudents)
+			protected.GET("/classes/:id/rankings", classHandler.GetRankings)
+			protected.GET("/classes/:id/broadsheet", classHandler.GetBroadsheet)
+			protected.POST("/classes/:id/report-cards", reportCardHandler.GenerateForClass)
 			protected.GET("/students", studentHandler.List)
 			protected.GET("/students/:id", studentHandler.Get)
 			protected.GET("/students/:id/results", resultHandler.GetByStudent)
+			protected.GET("/students/:id/summaries", resultHandler.GetSummariesByStudent)
+			protected.GET("/students/:id/report-cards", reportCardHandler.ListByStudent)
+			protected.POST("/students/:id/report-cards", reportCardHandler.Generate)
+			protected.GET("/report-cards/:id/download", reportCardHandler.Download)
+			protected.GET("/jobs/:id", jobHandler.Get)
+			protected.GET("/jobs/:id/download", jobHandler.Download)
 			protected.GET("/subjects", subjectHandler.ListStandardSubjects)
 			protected.GET("/subjects/levels", subjectHandler.GetLevels)
 			protected.POST("/results", resultHandler.CreateOrUpdate)
+			protected.POST("/results/compute", resultHandler.Compute)
+			protected.POST("/results/transition", resultHandler.TransitionClass)
+			protected.POST("/results/:id/submit", resultHandler.Transition(services.ResultActionSubmit))
+			protected.POST("/results/:id/approve", resultHandler.Transition(services.ResultActionApprove))
+			protected.POST("/results/:id/return", resultHandler.Transition(services.ResultActionReturn))
+			protected.POST("/results/:id/publish", resultHandler.Transition(services.ResultActionPublish))
+			protected.GET("/grading-rules", gradingRuleHandler.List)
+			protected.GET("/term-locks", termLockHandler.List)
+			protected.GET("/academic-years", calendarHandler.ListYears)
+			protected.GET("/academic-years/current-term", calendarHandler.CurrentTerm)
+			protected.GET("/teacher-assignments", assignmentHandler.List)
+			protected.GET("/me/assignments", assignmentHandler.Mine)
+			protected.GET("/assessments", assessmentHandler.List)
+			protected.POST("/assessments", assessmentHandler.Create)
+			protected.GET("/assessments/:id", assessmentHandler.Get)
+			protected.PUT("/assessments/:id", assessmentHandler.Update)
+			protected.GET("/assessments/:id/marks", assessmentHandler.GetMarks)
+			protected.POST("/assessments/:id/marks", assessmentHandler.EnterMarks)
+			protected.GET("/assessments/:id/mark-sheet", assessmentHandler.GetMarkSheet)
+			protected.POST("/assessments/:id/mark-sheet", assessmentHandler.UploadMarkSheet)
 			protected.POST("/debug/results", func(c *gin.Context) {
 				var body map[string]interface{}
 				c.ShouldBindJSON(&body)
@@ -218,9 +303,27 @@ func main() {
 	}
 
 	addr := fmt.Sprintf(":%s", cfg.Server.Port)
-	log.Printf("Server starting on %s", addr)
-	if err := r.Run(addr); err != nil {
-		log.Fatal("Failed to start server:", err)
+	srv := &http.Server{Addr: addr, Handler: r}
+	go func() {
+		log.Printf("Server starting on %s", addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	// Graceful shutdown: stop taking requests, then let the running job finish
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Server shutdown: %v", err)
+	}
+	if err := jobRunner.Shutdown(ctx); err != nil {
+		log.Printf("Job runner shutdown: %v", err)
 	}
 }
 
@@ -254,7 +357,7 @@ func handleCommand(cmd string) {
 }
 
 func seedAdmin(db *gorm.DB, cfg *config.Config) {
-	authService := services.NewAuthService(db, cfg)
+	authService := services.NewAuthService(db, cfg, services.NewPasswordHasher(cfg.Argon2))
 
 	var count int64
 	db.Model(&models.User{}).Where("role = ?", "system_admin").Count(&count)
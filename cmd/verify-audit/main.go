@@ -0,0 +1,44 @@
+// Command verify-audit walks the audit log's hash chain and reports the first
+// broken link. It exits non-zero if the chain has been tampered with.
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/school-system/backend/internal/config"
+	"github.com/school-system/backend/internal/database"
+	"github.com/school-system/backend/internal/services"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	report, err := services.NewAuditService(db).VerifyChain()
+	if err != nil {
+		log.Fatal("Verification failed:", err)
+	}
+
+	if report.Pending > 0 {
+		log.Printf("%d entries are waiting to be linked into the chain", report.Pending)
+	}
+	if report.Unchained > 0 {
+		log.Printf("%d entries have been taken out of the chain by clearing their sequence", report.Unchained)
+	}
+
+	if !report.Valid {
+		log.Printf("Audit chain BROKEN at sequence %d (entry %s): %s", report.FirstBreak.Sequence, report.FirstBreak.EntryID, report.FirstBreak.Reason)
+		log.Printf("%d entries verified before the break", report.Checked)
+		os.Exit(1)
+	}
+
+	log.Printf("Audit chain intact: %d entries verified, last sequence %d", report.Checked, report.LastSequence)
+}
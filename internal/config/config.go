@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -16,6 +17,7 @@ type Config struct {
 	Argon2     Argon2Config
 	CORS       CORSConfig
 	Monitoring MonitoringConfig
+	Storage    StorageConfig
 }
 
 type ServerConfig struct {
@@ -55,6 +57,23 @@ type MonitoringConfig struct {
 	PrometheusEnabled bool
 }
 
+// StorageConfig locates the files the API writes: generated report cards and
+// uploaded school logos. In production Dir must be a persistent volume, as the
+// container filesystem on Render and Railway is wiped on every deploy.
+type StorageConfig struct {
+	Dir string
+}
+
+// ReportCardDir is where generated report card PDFs are stored
+func (s StorageConfig) ReportCardDir() string {
+	return filepath.Join(s.Dir, "report-cards")
+}
+
+// LogoDir is where uploaded school logos are stored, served under /logos
+func (s StorageConfig) LogoDir() string {
+	return filepath.Join(s.Dir, "logos")
+}
+
 func Load() (*Config, error) {
 	godotenv.Load()
 
@@ -111,6 +130,9 @@ func Load() (*Config, error) {
 		Monitoring: MonitoringConfig{
 			PrometheusEnabled: getEnv("PROMETHEUS_ENABLED", "true") == "true",
 		},
+		Storage: StorageConfig{
+			Dir: getEnv("STORAGE_DIR", "storage"),
+		},
 	}
 
 	if cfg.JWT.Secret == "" {
@@ -0,0 +1,292 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// unauditedTables are not recorded: the audit log itself, and tokens, jobs and
+// figures derived from audited data
+var unauditedTables = map[string]bool{
+	"audit_logs":     true,
+	"refresh_tokens": true,
+	"jobs":           true,
+	"term_summaries": true,
+	"report_cards":   true,
+}
+
+// snapshotBatchSize is how many rows are read per query when snapshotting a
+// bulk update or delete; every row is recorded however many there are
+const snapshotBatchSize = 1000
+
+const auditBeforeKey = "audit:before"
+
+// RegisterAuditCallbacks records every create, update and delete in the audit
+// log with before and after snapshots. The actor and IP come from the
+// statement's context: handlers pass the gin context with db.WithContext(c),
+// which carries "user_id" and the client IP. Writes without one are logged
+// with a nil actor.
+func RegisterAuditCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:create").Register("audit:create", auditAfter("CREATE")); err != nil {
+		return err
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("audit:before_update", captureBefore); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:update").Register("audit:update", auditAfter("UPDATE")); err != nil {
+		return err
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("audit:before_delete", captureBefore); err != nil {
+		return err
+	}
+	return callbacks.Delete().After("gorm:delete").Register("audit:delete", auditAfter("DELETE"))
+}
+
+func audited(db *gorm.DB) bool {
+	if skip, _ := db.Get(models.SkipAudit); skip == true {
+		return false
+	}
+	stmt := db.Statement
+	return stmt.Schema != nil && stmt.Schema.PrioritizedPrimaryField != nil && !unauditedTables[stmt.Schema.Table]
+}
+
+// captureBefore snapshots the rows an update or delete is about to change. If
+// they cannot be read the write is stopped rather than left unaudited.
+func captureBefore(db *gorm.DB) {
+	if db.Error != nil || !audited(db) {
+		return
+	}
+	ids, err := targetIDs(db)
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: failed to find rows to snapshot: %w", err))
+		return
+	}
+	before, err := loadSnapshots(db, ids)
+	if err != nil {
+		db.AddError(fmt.Errorf("audit: failed to snapshot rows: %w", err))
+		return
+	}
+	db.Statement.Settings.Store(auditBeforeKey, before)
+}
+
+func auditAfter(action string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Error != nil || db.RowsAffected == 0 || !audited(db) {
+			return
+		}
+
+		var before map[interface{}]models.JSONB
+		if stored, ok := db.Statement.Settings.Load(auditBeforeKey); ok {
+			before = stored.(map[interface{}]models.JSONB)
+		}
+
+		var ids []interface{}
+		after := make(map[interface{}]models.JSONB)
+		switch action {
+		case "CREATE":
+			for _, rv := range modelValues(db) {
+				if id, zero := db.Statement.Schema.PrioritizedPrimaryField.ValueOf(db.Statement.Context, rv); !zero {
+					ids = append(ids, id)
+					after[id] = snapshot(db.Statement.Context, db.Statement.Schema, rv)
+				}
+			}
+		case "UPDATE":
+			for id := range before {
+				ids = append(ids, id)
+			}
+			var err error
+			if after, err = loadSnapshots(db, ids); err != nil {
+				db.AddError(fmt.Errorf("audit: failed to snapshot rows: %w", err))
+				return
+			}
+		case "DELETE":
+			for id := range before {
+				ids = append(ids, id)
+			}
+		}
+		if len(ids) == 0 {
+			return
+		}
+
+		actorID, ip := auditActor(db.Statement.Context)
+		resourceType := resourceType(db.Statement.Schema.Name)
+		logs := make([]models.AuditLog, 0, len(ids))
+		for _, id := range ids {
+			resourceID, _ := id.(uuid.UUID)
+			logs = append(logs, models.AuditLog{
+				ActorUserID:  actorID,
+				Action:       action,
+				ResourceType: resourceType,
+				ResourceID:   resourceID,
+				SchoolID:     auditSchool(db.Statement.Context, resourceType, resourceID, after[id], before[id]),
+				Before:       before[id],
+				After:        after[id],
+				IP:           ip,
+			})
+		}
+		// Written pending; the chainer links them once the write commits
+		if err := newSession(db).CreateInBatches(&logs, snapshotBatchSize).Error; err != nil {
+			db.AddError(err)
+		}
+	}
+}
+
+// targetIDs returns the primary keys of the rows a statement writes: those of
+// the model values it was given or, for bulk writes, every row its WHERE
+// clause matches
+func targetIDs(db *gorm.DB) ([]interface{}, error) {
+	stmt := db.Statement
+	var ids []interface{}
+	for _, rv := range modelValues(db) {
+		if id, zero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, rv); !zero {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > 0 {
+		return ids, nil
+	}
+
+	where, ok := stmt.Clauses["WHERE"]
+	if !ok {
+		return nil, nil
+	}
+	query := newSession(db).Table(stmt.Table).Clauses(where.Expression)
+	if hasSoftDelete(stmt.Schema) && !stmt.Unscoped {
+		query = query.Where(clause.Eq{Column: clause.Column{Table: stmt.Table, Name: "deleted_at"}, Value: nil})
+	}
+	err := query.Pluck(stmt.Schema.PrioritizedPrimaryField.DBName, &ids).Error
+	return ids, err
+}
+
+// loadSnapshots reads the current rows for the given primary keys, a batch at
+// a time
+func loadSnapshots(db *gorm.DB, ids []interface{}) (map[interface{}]models.JSONB, error) {
+	snapshots := make(map[interface{}]models.JSONB, len(ids))
+	stmt := db.Statement
+	for start := 0; start < len(ids); start += snapshotBatchSize {
+		end := start + snapshotBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+
+		rows := reflect.New(reflect.SliceOf(stmt.Schema.ModelType))
+		if err := newSession(db).Unscoped().Where(map[string]interface{}{stmt.Schema.PrioritizedPrimaryField.DBName: ids[start:end]}).
+			Find(rows.Interface()).Error; err != nil {
+			return nil, err
+		}
+		for i := 0; i < rows.Elem().Len(); i++ {
+			rv := rows.Elem().Index(i)
+			id, _ := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, rv)
+			snapshots[id] = snapshot(stmt.Context, stmt.Schema, rv)
+		}
+	}
+	return snapshots, nil
+}
+
+// snapshot copies a row's columns, leaving out relations and fields hidden
+// from JSON such as password hashes
+func snapshot(ctx context.Context, s *schema.Schema, rv reflect.Value) models.JSONB {
+	values := make(models.JSONB, len(s.Fields))
+	for _, field := range s.Fields {
+		if field.DBName == "" || field.Tag.Get("json") == "-" {
+			continue
+		}
+		value, _ := field.ValueOf(ctx, rv)
+		values[field.DBName] = value
+	}
+	return values
+}
+
+// modelValues returns the struct values a statement was given
+func modelValues(db *gorm.DB) []reflect.Value {
+	rv := reflect.Indirect(db.Statement.ReflectValue)
+	switch rv.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{rv}
+	case reflect.Slice, reflect.Array:
+		values := make([]reflect.Value, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			if elem := reflect.Indirect(rv.Index(i)); elem.Kind() == reflect.Struct {
+				values = append(values, elem)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func hasSoftDelete(s *schema.Schema) bool {
+	field := s.LookUpField("deleted_at")
+	return field != nil && field.FieldType == reflect.TypeOf(gorm.DeletedAt{})
+}
+
+// newSession starts a fresh statement on the same connection or transaction
+func newSession(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true})
+}
+
+// auditActor reads the acting user and client IP from a request context
+func auditActor(ctx context.Context) (uuid.UUID, string) {
+	if ctx == nil {
+		return uuid.Nil, ""
+	}
+	actorID, _ := ctx.Value("user_id").(uuid.UUID)
+	var ip string
+	if client, ok := ctx.(interface{ ClientIP() string }); ok {
+		ip = client.ClientIP()
+	}
+	return actorID, ip
+}
+
+// auditSchool works out which school's trail a change belongs to: the school
+// itself, the school_id of the row, or else the requesting user's school
+func auditSchool(ctx context.Context, resourceType string, resourceID uuid.UUID, snapshots ...models.JSONB) *uuid.UUID {
+	if resourceType == "school" {
+		return &resourceID
+	}
+	for _, values := range snapshots {
+		switch schoolID := values["school_id"].(type) {
+		case uuid.UUID:
+			return &schoolID
+		case *uuid.UUID:
+			if schoolID != nil {
+				return schoolID
+			}
+		}
+	}
+	if ctx == nil {
+		return nil
+	}
+	if tenant, ok := ctx.Value("tenant_school_id").(string); ok {
+		if schoolID, err := uuid.Parse(tenant); err == nil {
+			return &schoolID
+		}
+	}
+	return nil
+}
+
+// resourceType turns a model name into the audit resource type, e.g.
+// "SubjectResult" into "subject_result"
+func resourceType(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
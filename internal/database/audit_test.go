@@ -0,0 +1,178 @@
+package database
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newAuditedDB opens an in-memory SQLite database with the audit callbacks
+// registered
+func newAuditedDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	// Every connection to :memory: is a separate database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.Student{}, &models.User{}, &models.RefreshToken{}, &models.AuditLog{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if err := RegisterAuditCallbacks(db); err != nil {
+		t.Fatalf("Failed to register audit callbacks: %v", err)
+	}
+	return db
+}
+
+// requestContext is a gin context for a request from ip by the given user, as
+// handlers pass to db.WithContext
+func requestContext(actorID uuid.UUID, ip string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "/", nil)
+	c.Request.RemoteAddr = ip + ":41234"
+	c.Set("user_id", actorID)
+	return c
+}
+
+func auditLogs(t *testing.T, db *gorm.DB, action string) []models.AuditLog {
+	t.Helper()
+	var logs []models.AuditLog
+	if err := db.Where("action = ?", action).Order("timestamp").Find(&logs).Error; err != nil {
+		t.Fatalf("Failed to load audit logs: %v", err)
+	}
+	return logs
+}
+
+func TestAuditCallbacks_RecordsWritesWithActor(t *testing.T) {
+	db := newAuditedDB(t)
+	actorID := uuid.New()
+	schoolID := uuid.New()
+	ctx := requestContext(actorID, "10.1.2.3")
+
+	student := models.Student{SchoolID: schoolID, AdmissionNo: "001", FirstName: "Jane", LastName: "Doe"}
+	if err := db.WithContext(ctx).Create(&student).Error; err != nil {
+		t.Fatalf("Failed to create student: %v", err)
+	}
+	if err := db.WithContext(ctx).Model(&student).Update("first_name", "Janet").Error; err != nil {
+		t.Fatalf("Failed to update student: %v", err)
+	}
+	if err := db.WithContext(ctx).Delete(&student).Error; err != nil {
+		t.Fatalf("Failed to delete student: %v", err)
+	}
+
+	for _, action := range []string{"CREATE", "UPDATE", "DELETE"} {
+		logs := auditLogs(t, db, action)
+		if len(logs) != 1 {
+			t.Fatalf("%s: expected 1 entry, got %d", action, len(logs))
+		}
+		entry := logs[0]
+		if entry.ActorUserID != actorID || entry.IP != "10.1.2.3" {
+			t.Errorf("%s: expected actor %s from 10.1.2.3, got %s from %q", action, actorID, entry.ActorUserID, entry.IP)
+		}
+		if entry.ResourceType != "student" || entry.ResourceID != student.ID {
+			t.Errorf("%s: expected student %s, got %s %s", action, student.ID, entry.ResourceType, entry.ResourceID)
+		}
+		if entry.SchoolID == nil || *entry.SchoolID != schoolID {
+			t.Errorf("%s: expected the entry filed under school %s, got %v", action, schoolID, entry.SchoolID)
+		}
+	}
+
+	update := auditLogs(t, db, "UPDATE")[0]
+	if update.Before["first_name"] != "Jane" || update.After["first_name"] != "Janet" {
+		t.Errorf("Expected first_name Jane -> Janet, got %v -> %v", update.Before["first_name"], update.After["first_name"])
+	}
+	if deleted := auditLogs(t, db, "DELETE")[0]; deleted.Before["admission_no"] != "001" || len(deleted.After) > 0 {
+		t.Errorf("Expected the deleted row in before only, got %v -> %v", deleted.Before, deleted.After)
+	}
+}
+
+func TestAuditCallbacks_WithoutRequestContext(t *testing.T) {
+	db := newAuditedDB(t)
+
+	student := models.Student{SchoolID: uuid.New(), AdmissionNo: "001", FirstName: "Jane", LastName: "Doe"}
+	if err := db.Create(&student).Error; err != nil {
+		t.Fatalf("Failed to create student: %v", err)
+	}
+
+	logs := auditLogs(t, db, "CREATE")
+	if len(logs) != 1 || logs[0].ActorUserID != uuid.Nil || logs[0].IP != "" {
+		t.Errorf("Expected one entry with no actor or IP, got %+v", logs)
+	}
+}
+
+func TestAuditCallbacks_SkipsUnauditedTables(t *testing.T) {
+	db := newAuditedDB(t)
+	ctx := requestContext(uuid.New(), "10.1.2.3")
+
+	token := models.RefreshToken{UserID: uuid.New(), Token: "token", ExpiresAt: time.Now().Add(time.Hour)}
+	if err := db.WithContext(ctx).Create(&token).Error; err != nil {
+		t.Fatalf("Failed to create token: %v", err)
+	}
+	if err := db.WithContext(ctx).Model(&token).Update("revoked", true).Error; err != nil {
+		t.Fatalf("Failed to revoke token: %v", err)
+	}
+
+	var count int64
+	db.Model(&models.AuditLog{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected refresh tokens to go unaudited, got %d entries", count)
+	}
+}
+
+func TestAuditCallbacks_HidesSecrets(t *testing.T) {
+	db := newAuditedDB(t)
+
+	user := models.User{Email: "teacher@school.ug", PasswordHash: "secret-hash", Role: "teacher", FullName: "A Teacher"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	logs := auditLogs(t, db, "CREATE")
+	if len(logs) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(logs))
+	}
+	if _, ok := logs[0].After["password_hash"]; ok {
+		t.Error("Expected the password hash to be left out of the snapshot")
+	}
+	if logs[0].After["email"] != "teacher@school.ug" {
+		t.Errorf("Expected the email in the snapshot, got %v", logs[0].After)
+	}
+}
+
+func TestAuditCallbacks_BulkDeleteRecordsEveryRow(t *testing.T) {
+	db := newAuditedDB(t)
+	schoolID := uuid.New()
+
+	// More rows than one snapshot batch
+	count := snapshotBatchSize + 5
+	students := make([]models.Student, count)
+	for i := range students {
+		students[i] = models.Student{SchoolID: schoolID, AdmissionNo: fmt.Sprintf("%04d", i), FirstName: "Student", LastName: "Test"}
+	}
+	if err := db.CreateInBatches(&students, 500).Error; err != nil {
+		t.Fatalf("Failed to create students: %v", err)
+	}
+
+	if err := db.Where("school_id = ?", schoolID).Delete(&models.Student{}).Error; err != nil {
+		t.Fatalf("Failed to delete students: %v", err)
+	}
+
+	if logs := auditLogs(t, db, "DELETE"); len(logs) != count {
+		t.Errorf("Expected %d delete entries, got %d", count, len(logs))
+	}
+}
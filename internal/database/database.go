@@ -1,10 +1,12 @@
 package database
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 
 	"github.com/school-system/backend/internal/config"
+	"github.com/school-system/backend/internal/grading"
 	"github.com/school-system/backend/internal/models"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -29,6 +31,10 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	if err := RegisterAuditCallbacks(db); err != nil {
+		return nil, fmt.Errorf("failed to register audit callbacks: %w", err)
+	}
+
 	log.Println("Database connection successful")
 	return db, nil
 }
@@ -43,6 +49,11 @@ func maskPassword(dsn string) string {
 
 func Migrate(db *gorm.DB) error {
 	log.Println("Running migrations...")
+
+	// Results from before the approval workflow were already on report cards,
+	// so they start out published rather than as drafts
+	publishExisting := db.Migrator().HasTable(&models.SubjectResult{}) &&
+		!db.Migrator().HasColumn(&models.SubjectResult{}, "status")
 	
 	err := db.AutoMigrate(
 		&models.School{},
@@ -55,22 +66,94 @@ func Migrate(db *gorm.DB) error {
 		&models.Assessment{},
 		&models.Mark{},
 		&models.SubjectResult{},
+		&models.TermSummary{},
 		&models.ReportCard{},
 		&models.AuditLog{},
 		&models.Job{},
 		&models.GradingRule{},
 		&models.RefreshToken{},
+		&models.TermLock{},
+		&models.AcademicYear{},
+		&models.Term{},
+		&models.TeacherSubjectAssignment{},
 	)
 	if err != nil {
 		return err
 	}
 
+	// The plain sequence index gave way to the unique and pending ones
+	if db.Migrator().HasIndex(&models.AuditLog{}, "idx_audit_logs_sequence") {
+		if err := db.Migrator().DropIndex(&models.AuditLog{}, "idx_audit_logs_sequence"); err != nil {
+			return err
+		}
+	}
+
+	if publishExisting {
+		if err := db.Exec("UPDATE subject_results SET status = 'published'").Error; err != nil {
+			return err
+		}
+	}
+
+	if err := replaceLegacyPrimaryRules(db); err != nil {
+		return err
+	}
+
 	// Add performance indexes
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_users_email ON users(email)")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_students_school ON students(school_id)")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_classes_school_year ON classes(school_id, year)")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_marks_student ON marks(student_id)")
 	db.Exec("CREATE INDEX IF NOT EXISTS idx_standard_subjects_level ON standard_subjects(level)")
+
+	// Move the forced password change flag out of user meta and drop the
+	// plain-text default passwords stored there. Accounts that had one still
+	// carry its hash, so it is replaced with one no password matches; an admin
+	// issues them a new one-time password through the reset endpoint.
+	db.Exec("UPDATE users SET password_hash = '!' || md5(random()::text), must_change_password = true WHERE meta::jsonb ->> 'default_password' IS NOT NULL")
+	db.Exec("UPDATE users SET must_change_password = true WHERE meta::jsonb ->> 'must_change_password' = 'true'")
+	db.Exec("UPDATE users SET meta = (meta::jsonb - 'default_password' - 'must_change_password')::json WHERE meta::jsonb ->> 'default_password' IS NOT NULL OR meta::jsonb ->> 'must_change_password' IS NOT NULL")
 	
 	return nil
 }
+
+// legacyPrimaryRules is the primary grading rule seeded before
+// grading.DefaultPrimaryRules, which graded 1-5 where built-in grading gives A-E
+var legacyPrimaryRules = models.JSONB{
+	"type": "primary",
+	"grades": map[string]interface{}{
+		"1": map[string]interface{}{"min": 80, "max": 100, "description": "Excellent"},
+		"2": map[string]interface{}{"min": 70, "max": 79, "description": "Very Good"},
+		"3": map[string]interface{}{"min": 60, "max": 69, "description": "Good"},
+		"4": map[string]interface{}{"min": 50, "max": 59, "description": "Satisfactory"},
+		"5": map[string]interface{}{"min": 0, "max": 49, "description": "Needs Improvement"},
+	},
+}
+
+// replaceLegacyPrimaryRules moves schools still on the old seeded primary rule
+// to the A-E default. Rules a school has changed are left as they are.
+// Results already computed keep their grade until they are next computed.
+func replaceLegacyPrimaryRules(db *gorm.DB) error {
+	legacy, err := json.Marshal(legacyPrimaryRules)
+	if err != nil {
+		return err
+	}
+
+	var rules []models.GradingRule
+	if err := db.Where("rule_version = ?", "NCDC_PRIMARY_2023").Find(&rules).Error; err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		// Maps marshal with sorted keys, so equal rules give equal JSON
+		stored, err := json.Marshal(rule.Rules)
+		if err != nil || string(stored) != string(legacy) {
+			continue
+		}
+		if err := db.Model(&rule).Updates(map[string]interface{}{
+			"rule_version": grading.DefaultPrimaryVersion,
+			"rules":        models.JSONB(grading.DefaultPrimaryRules()),
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
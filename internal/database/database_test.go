@@ -0,0 +1,95 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/grading"
+	"github.com/school-system/backend/internal/models"
+)
+
+func TestMigrate_PublishesExistingResults(t *testing.T) {
+	db := newAuditedDB(t)
+
+	// A results table from before the approval workflow
+	if err := db.AutoMigrate(&models.SubjectResult{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	if err := db.Migrator().DropIndex(&models.SubjectResult{}, "idx_subject_results_status"); err != nil {
+		t.Fatalf("Failed to drop index: %v", err)
+	}
+	if err := db.Migrator().DropColumn(&models.SubjectResult{}, "status"); err != nil {
+		t.Fatalf("Failed to drop status: %v", err)
+	}
+	existing := uuid.New()
+	if err := db.Exec("INSERT INTO subject_results (id, student_id, subject_id, class_id, term, year, school_id) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		existing, uuid.New(), uuid.New(), uuid.New(), "Term1", 2024, uuid.New()).Error; err != nil {
+		t.Fatalf("Failed to insert result: %v", err)
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	var result models.SubjectResult
+	if err := db.First(&result, "id = ?", existing).Error; err != nil {
+		t.Fatalf("Failed to load result: %v", err)
+	}
+	if result.Status != "published" {
+		t.Errorf("Expected the existing result published, got %q", result.Status)
+	}
+
+	// Later runs leave new drafts alone
+	draft := models.SubjectResult{StudentID: uuid.New(), SubjectID: uuid.New(), ClassID: uuid.New(), SchoolID: uuid.New(), Term: "Term1", Year: 2025}
+	if err := db.Create(&draft).Error; err != nil {
+		t.Fatalf("Failed to create result: %v", err)
+	}
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	var stored models.SubjectResult
+	if err := db.First(&stored, "id = ?", draft.ID).Error; err != nil {
+		t.Fatalf("Failed to load result: %v", err)
+	}
+	if stored.Status != "draft" {
+		t.Errorf("Expected the new result to stay a draft, got %q", stored.Status)
+	}
+}
+
+func TestMigrate_ReplacesLegacyPrimaryRules(t *testing.T) {
+	db := newAuditedDB(t)
+	if err := db.AutoMigrate(&models.GradingRule{}); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	seeded := models.GradingRule{SchoolID: uuid.New(), Level: "P4", RuleVersion: "NCDC_PRIMARY_2023", Rules: legacyPrimaryRules}
+	customised := models.GradingRule{SchoolID: uuid.New(), Level: "P4", RuleVersion: "NCDC_PRIMARY_2023", Rules: models.JSONB{
+		"type": "primary",
+		"grades": map[string]interface{}{
+			"1": map[string]interface{}{"min": 75, "max": 100},
+			"2": map[string]interface{}{"min": 0, "max": 74},
+		},
+	}}
+	for _, rule := range []*models.GradingRule{&seeded, &customised} {
+		if err := db.Create(rule).Error; err != nil {
+			t.Fatalf("Failed to create rule: %v", err)
+		}
+	}
+
+	if err := Migrate(db); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+
+	var replaced, kept models.GradingRule
+	db.First(&replaced, "id = ?", seeded.ID)
+	db.First(&kept, "id = ?", customised.ID)
+	if replaced.RuleVersion != grading.DefaultPrimaryVersion {
+		t.Errorf("Expected the seeded rule moved to %s, got %s", grading.DefaultPrimaryVersion, replaced.RuleVersion)
+	}
+	if grades, _ := replaced.Rules["grades"].(map[string]interface{}); grades["A"] == nil || grades["1"] != nil {
+		t.Errorf("Expected A-E grades, got %v", replaced.Rules["grades"])
+	}
+	if kept.RuleVersion != "NCDC_PRIMARY_2023" || kept.Rules["grades"].(map[string]interface{})["1"] == nil {
+		t.Errorf("Expected the school's own rule left alone, got %s %v", kept.RuleVersion, kept.Rules)
+	}
+}
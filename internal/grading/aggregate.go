@@ -0,0 +1,159 @@
+package grading
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PLECoreSubjects are the four subjects counted in the PLE aggregate
+var PLECoreSubjects = []string{"ENG", "MATH", "SCI", "SST"}
+
+// uacePrincipalPoints is the UNEB points table used when a school's rule has no points
+var uacePrincipalPoints = map[string]int{"A": 6, "B": 5, "C": 4, "D": 3, "E": 2, "O": 1, "F": 0}
+
+// SubjectScore is one graded subject fed into an overall aggregate
+type SubjectScore struct {
+	Code       string
+	Grade      string
+	Total      float64
+	Subsidiary bool
+}
+
+// AggregateResult holds a student's overall standing for a term
+type AggregateResult struct {
+	Aggregate *int
+	Division  string
+	Points    *int
+	Reason    string
+}
+
+// PLECode maps a 0-100 subject total to the UNEB PLE code 1 (D1) to 9 (F9)
+func PLECode(total float64) int {
+	switch {
+	case total >= 80:
+		return 1
+	case total >= 70:
+		return 2
+	case total >= 65:
+		return 3
+	case total >= 60:
+		return 4
+	case total >= 55:
+		return 5
+	case total >= 50:
+		return 6
+	case total >= 45:
+		return 7
+	case total >= 40:
+		return 8
+	default:
+		return 9
+	}
+}
+
+// ComputePLEAggregate sums the codes of the four core subjects and assigns the division
+func ComputePLEAggregate(scores []SubjectScore) (AggregateResult, error) {
+	byCode := make(map[string]SubjectScore, len(scores))
+	for _, score := range scores {
+		byCode[strings.ToUpper(score.Code)] = score
+	}
+
+	aggregate := 0
+	parts := make([]string, 0, len(PLECoreSubjects))
+	for _, code := range PLECoreSubjects {
+		score, ok := byCode[code]
+		if !ok {
+			return AggregateResult{}, fmt.Errorf("%w: no %s result", ErrMissingComponent, code)
+		}
+		subjectCode := PLECode(score.Total)
+		aggregate += subjectCode
+		parts = append(parts, fmt.Sprintf("%s %.2f → %d", code, score.Total, subjectCode))
+	}
+
+	division := ""
+	switch {
+	case aggregate <= 12:
+		division = "I"
+	case aggregate <= 23:
+		division = "II"
+	case aggregate <= 29:
+		division = "III"
+	case aggregate <= 34:
+		division = "IV"
+	default:
+		division = "U"
+	}
+
+	return AggregateResult{
+		Aggregate: &aggregate,
+		Division:  division,
+		Reason:    fmt.Sprintf("%s → Aggregate %d → Division %s", strings.Join(parts, ", "), aggregate, division),
+	}, nil
+}
+
+// ComputeUACEPoints sums points for the best three principal subjects plus one point
+// per passed subsidiary. pointsFor looks up a school's rule points for a grade and
+// falls back to the UNEB table (A=6 ... O=1, F=0) when it returns false.
+func ComputeUACEPoints(scores []SubjectScore, pointsFor func(grade string) (int, bool)) (AggregateResult, error) {
+	type principal struct {
+		code   string
+		grade  string
+		points int
+	}
+
+	var principals []principal
+	subsidiaryPoints := 0
+	var subsidiaryParts []string
+
+	for _, score := range scores {
+		if score.Subsidiary {
+			points := 0
+			if score.Grade == "O" {
+				points = 1
+			}
+			subsidiaryPoints += points
+			subsidiaryParts = append(subsidiaryParts, fmt.Sprintf("%s %s = %d", score.Code, score.Grade, points))
+			continue
+		}
+
+		points, ok := 0, false
+		if pointsFor != nil {
+			points, ok = pointsFor(score.Grade)
+		}
+		if !ok {
+			points, ok = uacePrincipalPoints[score.Grade]
+		}
+		if !ok {
+			return AggregateResult{}, fmt.Errorf("%w: no points for grade %s in %s", ErrInvalidInput, score.Grade, score.Code)
+		}
+		principals = append(principals, principal{code: score.Code, grade: score.Grade, points: points})
+	}
+
+	if len(principals) == 0 {
+		return AggregateResult{}, fmt.Errorf("%w: no principal subject results", ErrMissingComponent)
+	}
+
+	sort.SliceStable(principals, func(i, j int) bool { return principals[i].points > principals[j].points })
+	if len(principals) > 3 {
+		principals = principals[:3]
+	}
+
+	total := subsidiaryPoints
+	parts := make([]string, 0, len(principals))
+	for _, p := range principals {
+		total += p.points
+		parts = append(parts, fmt.Sprintf("%s %s = %d", p.code, p.grade, p.points))
+	}
+
+	reason := fmt.Sprintf("Principals: %s", strings.Join(parts, ", "))
+	if len(subsidiaryParts) > 0 {
+		reason += fmt.Sprintf("; Subsidiaries: %s", strings.Join(subsidiaryParts, ", "))
+	}
+	reason += fmt.Sprintf(" → %d points", total)
+
+	return AggregateResult{
+		Points: &total,
+		Reason: reason,
+	}, nil
+}
@@ -0,0 +1,84 @@
+package grading
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestComputePLEAggregate(t *testing.T) {
+	tests := []struct {
+		name      string
+		totals    [4]float64 // ENG, MATH, SCI, SST
+		aggregate int
+		division  string
+	}{
+		{"All Distinction", [4]float64{95, 90, 85, 80}, 4, "I"},
+		{"Division I Upper Bound", [4]float64{65, 65, 65, 65}, 12, "I"},
+		{"Division II", [4]float64{60, 55, 50, 65}, 18, "II"},
+		{"Division III", [4]float64{45, 45, 50, 50}, 26, "III"},
+		{"Division IV", [4]float64{40, 40, 40, 45}, 31, "IV"},
+		{"Ungraded", [4]float64{30, 20, 10, 40}, 35, "U"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scores := []SubjectScore{
+				{Code: "ENG", Total: tt.totals[0]},
+				{Code: "MATH", Total: tt.totals[1]},
+				{Code: "SCI", Total: tt.totals[2]},
+				{Code: "SST", Total: tt.totals[3]},
+				{Code: "CA", Total: 0}, // Non-core subjects are ignored
+			}
+			result, err := ComputePLEAggregate(scores)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if *result.Aggregate != tt.aggregate || result.Division != tt.division {
+				t.Errorf("Expected aggregate %d division %s, got %d %s. Reason: %s",
+					tt.aggregate, tt.division, *result.Aggregate, result.Division, result.Reason)
+			}
+		})
+	}
+
+	t.Run("Missing core subject", func(t *testing.T) {
+		_, err := ComputePLEAggregate([]SubjectScore{{Code: "ENG", Total: 80}})
+		if !errors.Is(err, ErrMissingComponent) {
+			t.Errorf("Expected ErrMissingComponent, got %v", err)
+		}
+	})
+}
+
+func TestComputeUACEPoints(t *testing.T) {
+	scores := []SubjectScore{
+		{Code: "PHY", Grade: "A"},
+		{Code: "CHEM", Grade: "C"},
+		{Code: "MATH", Grade: "B"},
+		{Code: "BIO", Grade: "F"}, // Fourth principal, not counted
+		{Code: "GP", Grade: "O", Subsidiary: true},
+		{Code: "SUBMATH", Grade: "F", Subsidiary: true},
+	}
+
+	result, err := ComputeUACEPoints(scores, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if *result.Points != 16 {
+		t.Errorf("Expected 16 points, got %d. Reason: %s", *result.Points, result.Reason)
+	}
+
+	// School rule points take precedence over the UNEB table
+	custom := func(grade string) (int, bool) {
+		if grade == "A" {
+			return 10, true
+		}
+		return 0, false
+	}
+	result, _ = ComputeUACEPoints(scores, custom)
+	if *result.Points != 20 {
+		t.Errorf("Expected 20 points with custom rule, got %d", *result.Points)
+	}
+
+	if _, err := ComputeUACEPoints([]SubjectScore{{Code: "GP", Grade: "O", Subsidiary: true}}, nil); !errors.Is(err, ErrMissingComponent) {
+		t.Errorf("Expected ErrMissingComponent, got %v", err)
+	}
+}
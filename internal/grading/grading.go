@@ -2,30 +2,83 @@ package grading
 
 import (
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"sort"
+	"sync"
 )
 
 const (
 	RuleVersionPrimary   = "PRIMARY_V1"
 	RuleVersionNCDC      = "NCDC_V1"
 	RuleVersionUACE      = "UACE_V1"
+	RuleVersionECCE      = "ECCE_V1"
 )
 
+var (
+	ErrMissingComponent = errors.New("missing assessment component")
+	ErrInvalidInput     = errors.New("invalid grading input")
+)
+
+// Component is one weighted part of a subject's marks, e.g. CA or exam
+type Component struct {
+	Name   string
+	Score  float64
+	Max    float64
+	Weight float64 // Share of the final total in percent; zero uses the grader's default
+}
+
+// Input is the structured marks a Grader works from
+type Input struct {
+	Components []Component
+	Papers     []float64 // Percentage per paper for UACE
+	Subsidiary bool      // UACE subsidiary subject graded on a single paper
+	Descriptor string    // Teacher-assessed ECCE descriptor
+}
+
+// Component returns the named component, if present
+func (in Input) Component(name string) (Component, bool) {
+	for _, c := range in.Components {
+		if c.Name == name && c.Max > 0 {
+			return c, true
+		}
+	}
+	return Component{}, false
+}
+
+// Grader computes a subject grade for a level
+type Grader interface {
+	Grade(input Input) (GradeResult, error)
+}
+
 // GradeResult holds computed grade information
 type GradeResult struct {
 	FinalGrade        string
 	ComputationReason string
 	RuleVersionHash   string
+	Total             float64        // Weighted percentage (mean paper mark for UACE)
 	PaperCodes        map[string]int // For UACE
 }
 
-// PrimaryGrader implements P4-P7 grading
+// PrimaryGrader implements primary grading (CA 40%, exam 60%)
 type PrimaryGrader struct{}
 
 func (g *PrimaryGrader) ComputeGrade(caMarks, examMarks, caMax, examMax float64) GradeResult {
-	caPercent := (caMarks / caMax) * 40
-	examPercent := (examMarks / examMax) * 60
+	return g.computeWeighted(caMarks, examMarks, caMax, examMax, 40, 60)
+}
+
+func (g *PrimaryGrader) Grade(in Input) (GradeResult, error) {
+	ca, exam, err := caExamComponents(in, "CA", "exam")
+	if err != nil {
+		return GradeResult{}, err
+	}
+	caWeight, examWeight := componentWeights(ca, exam, 40, 60)
+	return g.computeWeighted(ca.Score, exam.Score, ca.Max, exam.Max, caWeight, examWeight), nil
+}
+
+func (g *PrimaryGrader) computeWeighted(caMarks, examMarks, caMax, examMax, caWeight, examWeight float64) GradeResult {
+	caPercent := (caMarks / caMax) * caWeight
+	examPercent := (examMarks / examMax) * examWeight
 	total := caPercent + examPercent
 
 	grade := ""
@@ -42,13 +95,14 @@ func (g *PrimaryGrader) ComputeGrade(caMarks, examMarks, caMax, examMax float64)
 		grade = "E"
 	}
 
-	reason := fmt.Sprintf("CA: %.2f/%.0f (40%%) = %.2f, Exam: %.2f/%.0f (60%%) = %.2f, Total: %.2f → Grade %s",
-		caMarks, caMax, caPercent, examMarks, examMax, examPercent, total, grade)
+	reason := fmt.Sprintf("CA: %.2f/%.0f (%.0f%%) = %.2f, Exam: %.2f/%.0f (%.0f%%) = %.2f, Total: %.2f → Grade %s",
+		caMarks, caMax, caWeight, caPercent, examMarks, examMax, examWeight, examPercent, total, grade)
 
 	return GradeResult{
 		FinalGrade:        grade,
 		ComputationReason: reason,
 		RuleVersionHash:   hashRuleVersion(RuleVersionPrimary),
+		Total:             total,
 	}
 }
 
@@ -56,8 +110,21 @@ func (g *PrimaryGrader) ComputeGrade(caMarks, examMarks, caMax, examMax float64)
 type NCDCGrader struct{}
 
 func (g *NCDCGrader) ComputeGrade(schoolBasedMarks, externalMarks, schoolBasedMax, externalMax float64) GradeResult {
-	sbPercent := (schoolBasedMarks / schoolBasedMax) * 20
-	extPercent := (externalMarks / externalMax) * 80
+	return g.computeWeighted(schoolBasedMarks, externalMarks, schoolBasedMax, externalMax, 20, 80)
+}
+
+func (g *NCDCGrader) Grade(in Input) (GradeResult, error) {
+	sb, ext, err := caExamComponents(in, "school-based", "external")
+	if err != nil {
+		return GradeResult{}, err
+	}
+	sbWeight, extWeight := componentWeights(sb, ext, 20, 80)
+	return g.computeWeighted(sb.Score, ext.Score, sb.Max, ext.Max, sbWeight, extWeight), nil
+}
+
+func (g *NCDCGrader) computeWeighted(schoolBasedMarks, externalMarks, schoolBasedMax, externalMax, sbWeight, extWeight float64) GradeResult {
+	sbPercent := (schoolBasedMarks / schoolBasedMax) * sbWeight
+	extPercent := (externalMarks / externalMax) * extWeight
 	total := sbPercent + extPercent
 
 	grade := ""
@@ -74,13 +141,14 @@ func (g *NCDCGrader) ComputeGrade(schoolBasedMarks, externalMarks, schoolBasedMa
 		grade = "E"
 	}
 
-	reason := fmt.Sprintf("School-Based: %.2f/%.0f (20%%) = %.2f, External: %.2f/%.0f (80%%) = %.2f, Total: %.2f → Grade %s",
-		schoolBasedMarks, schoolBasedMax, sbPercent, externalMarks, externalMax, extPercent, total, grade)
+	reason := fmt.Sprintf("School-Based: %.2f/%.0f (%.0f%%) = %.2f, External: %.2f/%.0f (%.0f%%) = %.2f, Total: %.2f → Grade %s",
+		schoolBasedMarks, schoolBasedMax, sbWeight, sbPercent, externalMarks, externalMax, extWeight, extPercent, total, grade)
 
 	return GradeResult{
 		FinalGrade:        grade,
 		ComputationReason: reason,
 		RuleVersionHash:   hashRuleVersion(RuleVersionNCDC),
+		Total:             total,
 	}
 }
 
@@ -111,6 +179,37 @@ func (g *UACEGrader) MapMarkToCode(marks float64) int {
 	}
 }
 
+func (g *UACEGrader) Grade(in Input) (GradeResult, error) {
+	if in.Subsidiary {
+		return g.computeSubsidiary(in.Papers)
+	}
+	if len(in.Papers) == 0 {
+		return GradeResult{}, fmt.Errorf("%w: paper marks are required", ErrMissingComponent)
+	}
+	return g.ComputeGradeFromPapers(in.Papers), nil
+}
+
+// computeSubsidiary grades a single-paper subsidiary: codes 1-6 pass (O), 7-9 fail (F)
+func (g *UACEGrader) computeSubsidiary(papers []float64) (GradeResult, error) {
+	if len(papers) != 1 {
+		return GradeResult{}, fmt.Errorf("%w: subsidiary subjects have exactly one paper, got %d", ErrInvalidInput, len(papers))
+	}
+
+	code := g.MapMarkToCode(papers[0])
+	grade := "F"
+	if code <= 6 {
+		grade = "O"
+	}
+
+	return GradeResult{
+		FinalGrade:        grade,
+		ComputationReason: fmt.Sprintf("Subsidiary paper: %.2f → Code %d → Grade %s", papers[0], code, grade),
+		RuleVersionHash:   hashRuleVersion(RuleVersionUACE),
+		Total:             papers[0],
+		PaperCodes:        map[string]int{"Paper1": code},
+	}, nil
+}
+
 // ComputeGradeFromPapers computes final grade from paper marks
 func (g *UACEGrader) ComputeGradeFromPapers(paperMarks []float64) GradeResult {
 	numPapers := len(paperMarks)
@@ -147,10 +246,16 @@ func (g *UACEGrader) ComputeGradeFromPapers(paperMarks []float64) GradeResult {
 		finalGrade, reason = g.compute4Papers(sortedCodes)
 	}
 
+	sum := 0.0
+	for _, mark := range paperMarks {
+		sum += mark
+	}
+
 	return GradeResult{
 		FinalGrade:        finalGrade,
 		ComputationReason: fmt.Sprintf("Papers: %v → Codes: %v → %s", paperMarks, codes, reason),
 		RuleVersionHash:   hashRuleVersion(RuleVersionUACE),
+		Total:             sum / float64(numPapers),
 		PaperCodes:        paperCodes,
 	}
 }
@@ -224,16 +329,138 @@ func hashRuleVersion(version string) string {
 	return fmt.Sprintf("%x", hash[:8])
 }
 
-// GetGrader returns appropriate grader for level
-func GetGrader(level string) interface{} {
-	switch level {
-	case "P4", "P5", "P6", "P7":
-		return &PrimaryGrader{}
-	case "S1", "S2", "S3", "S4":
-		return &NCDCGrader{}
-	case "S5", "S6":
-		return &UACEGrader{}
+// DescriptorGrader implements ECCE/nursery grading with descriptors (E/VG/G/S/NI)
+type DescriptorGrader struct{}
+
+var ecceDescriptors = map[string]string{
+	"E":  "Excellent",
+	"VG": "Very Good",
+	"G":  "Good",
+	"S":  "Satisfactory",
+	"NI": "Needs Improvement",
+}
+
+func (g *DescriptorGrader) Grade(in Input) (GradeResult, error) {
+	if in.Descriptor != "" {
+		description, ok := ecceDescriptors[in.Descriptor]
+		if !ok {
+			return GradeResult{}, fmt.Errorf("%w: unknown descriptor %s", ErrInvalidInput, in.Descriptor)
+		}
+		return GradeResult{
+			FinalGrade:        in.Descriptor,
+			ComputationReason: fmt.Sprintf("Teacher assessed: %s", description),
+			RuleVersionHash:   hashRuleVersion(RuleVersionECCE),
+		}, nil
+	}
+
+	// Without a descriptor, derive one from the weighted mean of the components
+	weights := 0.0
+	total := 0.0
+	for _, c := range in.Components {
+		if c.Max <= 0 {
+			continue
+		}
+		weight := c.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		total += c.Score / c.Max * 100 * weight
+		weights += weight
+	}
+	if weights == 0 {
+		return GradeResult{}, fmt.Errorf("%w: a descriptor or marks are required", ErrMissingComponent)
+	}
+	total /= weights
+
+	grade := ""
+	switch {
+	case total >= 80:
+		grade = "E"
+	case total >= 70:
+		grade = "VG"
+	case total >= 60:
+		grade = "G"
+	case total >= 50:
+		grade = "S"
 	default:
-		return nil
+		grade = "NI"
+	}
+
+	return GradeResult{
+		FinalGrade:        grade,
+		ComputationReason: fmt.Sprintf("Average: %.2f → %s → Grade %s", total, ecceDescriptors[grade], grade),
+		RuleVersionHash:   hashRuleVersion(RuleVersionECCE),
+		Total:             total,
+	}, nil
+}
+
+func caExamComponents(in Input, caLabel, examLabel string) (Component, Component, error) {
+	ca, ok := in.Component("ca")
+	if !ok {
+		return Component{}, Component{}, fmt.Errorf("%w: %s marks are required", ErrMissingComponent, caLabel)
+	}
+	exam, ok := in.Component("exam")
+	if !ok {
+		return Component{}, Component{}, fmt.Errorf("%w: %s marks are required", ErrMissingComponent, examLabel)
+	}
+	return ca, exam, nil
+}
+
+func componentWeights(ca, exam Component, caDefault, examDefault float64) (float64, float64) {
+	if ca.Weight == 0 && exam.Weight == 0 {
+		return caDefault, examDefault
+	}
+	return ca.Weight, exam.Weight
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Grader)
+)
+
+func init() {
+	primary := &PrimaryGrader{}
+	ncdc := &NCDCGrader{}
+	uace := &UACEGrader{}
+	descriptor := &DescriptorGrader{}
+
+	for _, level := range []string{"ECCE", "Nursery", "Baby", "Middle", "Top"} {
+		Register(level, descriptor)
+	}
+	for _, level := range []string{"P1", "P2", "P3", "P4", "P5", "P6", "P7"} {
+		Register(level, primary)
+	}
+	for _, level := range []string{"S1", "S2", "S3", "S4"} {
+		Register(level, ncdc)
+	}
+	for _, level := range []string{"S5", "S6"} {
+		Register(level, uace)
+	}
+}
+
+// Register sets the grader used for a level, replacing any existing one
+func Register(level string, g Grader) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[level] = g
+}
+
+// GetGrader returns the grader registered for a level
+func GetGrader(level string) (Grader, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	g, ok := registry[level]
+	return g, ok
+}
+
+// Levels returns every level with a registered grader
+func Levels() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	levels := make([]string, 0, len(registry))
+	for level := range registry {
+		levels = append(levels, level)
 	}
+	sort.Strings(levels)
+	return levels
 }
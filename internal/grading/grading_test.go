@@ -1,6 +1,7 @@
 package grading
 
 import (
+	"errors"
 	"testing"
 )
 
@@ -203,3 +204,90 @@ func TestUACEGrader_EdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestGetGrader_Registry(t *testing.T) {
+	levels := []string{"Baby", "Middle", "Top", "Nursery", "ECCE", "P1", "P3", "P4", "P7", "S1", "S4", "S5", "S6"}
+	for _, level := range levels {
+		if _, ok := GetGrader(level); !ok {
+			t.Errorf("Expected a grader for level %s", level)
+		}
+	}
+
+	if _, ok := GetGrader("X9"); ok {
+		t.Errorf("Expected no grader for unknown level")
+	}
+
+	if len(Levels()) < len(levels) {
+		t.Errorf("Expected at least %d registered levels, got %d", len(levels), len(Levels()))
+	}
+}
+
+func TestGrader_StructuredInput(t *testing.T) {
+	input := Input{Components: []Component{
+		{Name: "ca", Score: 32, Max: 40},
+		{Name: "exam", Score: 48, Max: 60},
+	}}
+
+	primary, _ := GetGrader("P5")
+	result, err := primary.Grade(input)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.FinalGrade != "A" || result.Total != 80 {
+		t.Errorf("Expected A with total 80, got %s with %.2f", result.FinalGrade, result.Total)
+	}
+
+	weighted := Input{Components: []Component{
+		{Name: "ca", Score: 100, Max: 100, Weight: 50},
+		{Name: "exam", Score: 0, Max: 100, Weight: 50},
+	}}
+	result, _ = primary.Grade(weighted)
+	if result.Total != 50 {
+		t.Errorf("Expected custom weights to give 50, got %.2f", result.Total)
+	}
+
+	if _, err := primary.Grade(Input{Components: input.Components[:1]}); !errors.Is(err, ErrMissingComponent) {
+		t.Errorf("Expected ErrMissingComponent, got %v", err)
+	}
+}
+
+func TestUACEGrader_Subsidiary(t *testing.T) {
+	grader := &UACEGrader{}
+
+	tests := []struct {
+		mark     float64
+		expected string
+	}{
+		{80, "O"},
+		{50, "O"},
+		{49, "F"},
+	}
+
+	for _, tt := range tests {
+		result, err := grader.Grade(Input{Papers: []float64{tt.mark}, Subsidiary: true})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if result.FinalGrade != tt.expected {
+			t.Errorf("Mark %.0f: expected %s, got %s", tt.mark, tt.expected, result.FinalGrade)
+		}
+	}
+}
+
+func TestDescriptorGrader(t *testing.T) {
+	grader := &DescriptorGrader{}
+
+	result, err := grader.Grade(Input{Descriptor: "VG"})
+	if err != nil || result.FinalGrade != "VG" {
+		t.Errorf("Expected VG, got %s (%v)", result.FinalGrade, err)
+	}
+
+	if _, err := grader.Grade(Input{Descriptor: "A"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("Expected ErrInvalidInput for unknown descriptor, got %v", err)
+	}
+
+	result, _ = grader.Grade(Input{Components: []Component{{Name: "exam", Score: 13, Max: 20}}})
+	if result.FinalGrade != "G" {
+		t.Errorf("Expected G for 65%%, got %s", result.FinalGrade)
+	}
+}
@@ -0,0 +1,219 @@
+package grading
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidRules   = errors.New("invalid grading rules")
+	ErrDescriptorRule = errors.New("grading rule has descriptors only and cannot grade scores")
+)
+
+// Band is one grade of a grading rule with its score range
+type Band struct {
+	Grade       string
+	Min         float64
+	Max         float64
+	Points      *int
+	Description string
+}
+
+// RuleSet is a validated grading rule as stored in GradingRule.Rules:
+//
+//	{"type": "ordinary", "grades": {"D1": {"min": 80, "max": 100, "points": 1}, ...}}
+//
+// Descriptor-only rules (ECCE) list grades with a description and no range.
+type RuleSet struct {
+	Type    string
+	Version string
+	Bands   []Band // Ordered by Min ascending; descriptor-only rules by grade
+	ranged  bool
+	hash    string
+}
+
+// DefaultPrimaryVersion is the rule version of DefaultPrimaryRules
+const DefaultPrimaryVersion = "NCDC_PRIMARY_2024"
+
+// DefaultPrimaryRules is the grading rule seeded for primary levels. It holds
+// PrimaryGrader's A-E bands, so a school on the default rule grades exactly as
+// built-in grading does.
+func DefaultPrimaryRules() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "primary",
+		"grades": map[string]interface{}{
+			"A": map[string]interface{}{"min": 80, "max": 100, "description": "Excellent"},
+			"B": map[string]interface{}{"min": 65, "max": 79, "description": "Very Good"},
+			"C": map[string]interface{}{"min": 50, "max": 64, "description": "Good"},
+			"D": map[string]interface{}{"min": 35, "max": 49, "description": "Satisfactory"},
+			"E": map[string]interface{}{"min": 0, "max": 34, "description": "Needs Improvement"},
+		},
+	}
+}
+
+// ParseRuleSet validates the rules JSON of a GradingRule and builds a RuleSet
+func ParseRuleSet(version string, rules map[string]interface{}) (*RuleSet, error) {
+	ruleType, ok := rules["type"].(string)
+	if !ok || ruleType == "" {
+		return nil, fmt.Errorf("%w: missing type", ErrInvalidRules)
+	}
+
+	grades, ok := rules["grades"].(map[string]interface{})
+	if !ok || len(grades) == 0 {
+		return nil, fmt.Errorf("%w: grades must be a non-empty object", ErrInvalidRules)
+	}
+
+	bands := make([]Band, 0, len(grades))
+	ranged := 0
+	for grade, raw := range grades {
+		def, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: grade %s must be an object", ErrInvalidRules, grade)
+		}
+
+		band := Band{Grade: grade}
+		if description, ok := def["description"].(string); ok {
+			band.Description = description
+		}
+
+		if points, ok, err := ruleNumber(def, "points"); err != nil {
+			return nil, fmt.Errorf("%w: grade %s %v", ErrInvalidRules, grade, err)
+		} else if ok {
+			p := int(points)
+			band.Points = &p
+		}
+
+		min, hasMin, err := ruleNumber(def, "min")
+		if err != nil {
+			return nil, fmt.Errorf("%w: grade %s %v", ErrInvalidRules, grade, err)
+		}
+		max, hasMax, err := ruleNumber(def, "max")
+		if err != nil {
+			return nil, fmt.Errorf("%w: grade %s %v", ErrInvalidRules, grade, err)
+		}
+		if hasMin != hasMax {
+			return nil, fmt.Errorf("%w: grade %s must define both min and max", ErrInvalidRules, grade)
+		}
+		if hasMin {
+			if min < 0 || max > 100 || min > max {
+				return nil, fmt.Errorf("%w: grade %s range %g-%g must lie within 0-100", ErrInvalidRules, grade, min, max)
+			}
+			band.Min, band.Max = min, max
+			ranged++
+		}
+
+		bands = append(bands, band)
+	}
+
+	if ranged > 0 && ranged != len(bands) {
+		return nil, fmt.Errorf("%w: either every grade or no grade must define a range", ErrInvalidRules)
+	}
+
+	if ranged > 0 {
+		sort.Slice(bands, func(i, j int) bool { return bands[i].Min < bands[j].Min })
+
+		if bands[0].Min != 0 {
+			return nil, fmt.Errorf("%w: lowest grade %s must start at 0", ErrInvalidRules, bands[0].Grade)
+		}
+		if top := bands[len(bands)-1]; top.Max != 100 {
+			return nil, fmt.Errorf("%w: highest grade %s must end at 100", ErrInvalidRules, top.Grade)
+		}
+		for i := 1; i < len(bands); i++ {
+			prev, next := bands[i-1], bands[i]
+			if next.Min <= prev.Max {
+				return nil, fmt.Errorf("%w: grades %s and %s overlap", ErrInvalidRules, prev.Grade, next.Grade)
+			}
+			if next.Min-prev.Max > 1 {
+				return nil, fmt.Errorf("%w: gap between grades %s and %s", ErrInvalidRules, prev.Grade, next.Grade)
+			}
+		}
+	} else {
+		sort.Slice(bands, func(i, j int) bool { return bands[i].Grade < bands[j].Grade })
+	}
+
+	content, err := json.Marshal(map[string]interface{}{"rule_version": version, "rules": rules})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRules, err)
+	}
+	hash := sha256.Sum256(content)
+
+	return &RuleSet{
+		Type:    ruleType,
+		Version: version,
+		Bands:   bands,
+		ranged:  ranged > 0,
+		hash:    fmt.Sprintf("%x", hash[:8]),
+	}, nil
+}
+
+// Hash identifies the exact rule content used to compute a grade
+func (r *RuleSet) Hash() string {
+	return r.hash
+}
+
+// HasRanges reports whether the rule can grade numeric scores
+func (r *RuleSet) HasRanges() bool {
+	return r.ranged
+}
+
+// Band returns the band a 0-100 score falls in. Whole-number ranges such as
+// 70-79 and 80-100 are treated as [70, 80) so fractional scores always match.
+func (r *RuleSet) Band(score float64) (Band, error) {
+	if !r.HasRanges() {
+		return Band{}, ErrDescriptorRule
+	}
+	for i := len(r.Bands) - 1; i >= 0; i-- {
+		if score >= r.Bands[i].Min {
+			return r.Bands[i], nil
+		}
+	}
+	return r.Bands[0], nil
+}
+
+// Points returns the points a grade is worth, if the rule defines them
+func (r *RuleSet) Points(grade string) (int, bool) {
+	for _, band := range r.Bands {
+		if band.Grade == grade && band.Points != nil {
+			return *band.Points, true
+		}
+	}
+	return 0, false
+}
+
+// Apply regrades a grader's weighted total with the rule's bands
+func (r *RuleSet) Apply(result GradeResult) (GradeResult, error) {
+	band, err := r.Band(result.Total)
+	if err != nil {
+		return result, err
+	}
+
+	// Keep the grader's component breakdown but replace its built-in grade
+	reason := result.ComputationReason
+	if i := strings.LastIndex(reason, " → Grade "); i >= 0 {
+		reason = reason[:i]
+	}
+
+	result.FinalGrade = band.Grade
+	result.ComputationReason = fmt.Sprintf("%s → %s band %g-%g → Grade %s", reason, r.Version, band.Min, band.Max, band.Grade)
+	result.RuleVersionHash = r.hash
+	return result, nil
+}
+
+func ruleNumber(def map[string]interface{}, key string) (float64, bool, error) {
+	raw, ok := def[key]
+	if !ok {
+		return 0, false, nil
+	}
+	switch v := raw.(type) {
+	case float64:
+		return v, true, nil
+	case int:
+		return float64(v), true, nil
+	default:
+		return 0, false, fmt.Errorf("%s must be a number", key)
+	}
+}
@@ -0,0 +1,174 @@
+package grading
+
+import (
+	"errors"
+	"testing"
+)
+
+func ordinaryRules() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "ordinary",
+		"grades": map[string]interface{}{
+			"D1": map[string]interface{}{"min": 80, "max": 100, "points": 1},
+			"D2": map[string]interface{}{"min": 70, "max": 79, "points": 2},
+			"C3": map[string]interface{}{"min": 65, "max": 69, "points": 3},
+			"C4": map[string]interface{}{"min": 60, "max": 64, "points": 4},
+			"C5": map[string]interface{}{"min": 55, "max": 59, "points": 5},
+			"C6": map[string]interface{}{"min": 50, "max": 54, "points": 6},
+			"P7": map[string]interface{}{"min": 45, "max": 49, "points": 7},
+			"P8": map[string]interface{}{"min": 40, "max": 44, "points": 8},
+			"F9": map[string]interface{}{"min": 0, "max": 39, "points": 9},
+		},
+	}
+}
+
+func TestRuleSet_Band(t *testing.T) {
+	rules, err := ParseRuleSet("UNEB_ORDINARY_2023", ordinaryRules())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		score    float64
+		expected string
+	}{
+		{100, "D1"},
+		{80, "D1"},
+		{79.5, "D2"},
+		{70, "D2"},
+		{69.99, "C3"},
+		{50, "C6"},
+		{44, "P8"},
+		{39.9, "F9"},
+		{0, "F9"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			band, err := rules.Band(tt.score)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if band.Grade != tt.expected {
+				t.Errorf("Score %.2f: expected grade %s, got %s", tt.score, tt.expected, band.Grade)
+			}
+		})
+	}
+}
+
+func TestRuleSet_Points(t *testing.T) {
+	rules, _ := ParseRuleSet("UNEB_ORDINARY_2023", ordinaryRules())
+
+	if points, ok := rules.Points("C4"); !ok || points != 4 {
+		t.Errorf("Expected 4 points for C4, got %d (%v)", points, ok)
+	}
+	if _, ok := rules.Points("X"); ok {
+		t.Errorf("Expected no points for unknown grade")
+	}
+}
+
+func TestRuleSet_Apply(t *testing.T) {
+	rules, _ := ParseRuleSet("UNEB_ORDINARY_2023", ordinaryRules())
+	graded := (&NCDCGrader{}).ComputeGrade(60, 70, 100, 100) // 12 + 56 = 68
+
+	result, err := rules.Apply(graded)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.FinalGrade != "C3" {
+		t.Errorf("Expected grade C3, got %s. Reason: %s", result.FinalGrade, result.ComputationReason)
+	}
+	if result.RuleVersionHash != rules.Hash() {
+		t.Errorf("Expected rule hash %s, got %s", rules.Hash(), result.RuleVersionHash)
+	}
+}
+
+func TestRuleSet_Hash(t *testing.T) {
+	a, _ := ParseRuleSet("UNEB_ORDINARY_2023", ordinaryRules())
+	b, _ := ParseRuleSet("UNEB_ORDINARY_2023", ordinaryRules())
+	if a.Hash() != b.Hash() {
+		t.Errorf("Expected identical rules to hash equally")
+	}
+
+	changed := ordinaryRules()
+	changed["grades"].(map[string]interface{})["D1"] = map[string]interface{}{"min": 85, "max": 100, "points": 1}
+	changed["grades"].(map[string]interface{})["D2"] = map[string]interface{}{"min": 70, "max": 84, "points": 2}
+	c, err := ParseRuleSet("UNEB_ORDINARY_2023", changed)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if a.Hash() == c.Hash() {
+		t.Errorf("Expected changed cut-offs to change the hash")
+	}
+}
+
+func TestRuleSet_Descriptors(t *testing.T) {
+	rules, err := ParseRuleSet("NCDC_ECCE_2023", map[string]interface{}{
+		"type": "ecce",
+		"grades": map[string]interface{}{
+			"E":  map[string]interface{}{"description": "Excellent"},
+			"NI": map[string]interface{}{"description": "Needs Improvement"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := rules.Band(50); !errors.Is(err, ErrDescriptorRule) {
+		t.Errorf("Expected ErrDescriptorRule, got %v", err)
+	}
+}
+
+func TestParseRuleSet_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules map[string]interface{}
+	}{
+		{"Missing type", map[string]interface{}{"grades": map[string]interface{}{"A": map[string]interface{}{"min": 0, "max": 100}}}},
+		{"Missing grades", map[string]interface{}{"type": "primary"}},
+		{"Overlap", map[string]interface{}{"type": "primary", "grades": map[string]interface{}{
+			"A": map[string]interface{}{"min": 50, "max": 100},
+			"B": map[string]interface{}{"min": 0, "max": 60},
+		}}},
+		{"Gap", map[string]interface{}{"type": "primary", "grades": map[string]interface{}{
+			"A": map[string]interface{}{"min": 60, "max": 100},
+			"B": map[string]interface{}{"min": 0, "max": 49},
+		}}},
+		{"Does not reach 100", map[string]interface{}{"type": "primary", "grades": map[string]interface{}{
+			"A": map[string]interface{}{"min": 0, "max": 90},
+		}}},
+		{"Mixed ranges and descriptors", map[string]interface{}{"type": "primary", "grades": map[string]interface{}{
+			"A": map[string]interface{}{"min": 0, "max": 100},
+			"B": map[string]interface{}{"description": "Good"},
+		}}},
+		{"Non-numeric bound", map[string]interface{}{"type": "primary", "grades": map[string]interface{}{
+			"A": map[string]interface{}{"min": "0", "max": 100},
+		}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseRuleSet("TEST", tt.rules); !errors.Is(err, ErrInvalidRules) {
+				t.Errorf("Expected ErrInvalidRules, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDefaultPrimaryRules_MatchBuiltIn(t *testing.T) {
+	rules, err := ParseRuleSet(DefaultPrimaryVersion, DefaultPrimaryRules())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	grader := &PrimaryGrader{}
+	for _, total := range []float64{0, 20, 34.99, 35, 49.5, 50, 64.99, 65, 79.99, 80, 100} {
+		builtIn := grader.ComputeGrade(total, total, 100, 100)
+		applied, err := rules.Apply(builtIn)
+		if err != nil {
+			t.Fatalf("%.2f: unexpected error: %v", total, err)
+		}
+		if applied.FinalGrade != builtIn.FinalGrade {
+			t.Errorf("%.2f: expected %s as built-in grading gives, got %s", total, builtIn.FinalGrade, applied.FinalGrade)
+		}
+	}
+}
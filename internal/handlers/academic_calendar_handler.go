@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"github.com/school-system/backend/internal/services"
+	"gorm.io/gorm"
+)
+
+type AcademicCalendarHandler struct {
+	db              *gorm.DB
+	calendarService *services.AcademicCalendarService
+}
+
+func NewAcademicCalendarHandler(db *gorm.DB) *AcademicCalendarHandler {
+	return &AcademicCalendarHandler{
+		db:              db,
+		calendarService: services.NewAcademicCalendarService(db),
+	}
+}
+
+// ListYears returns the school's academic years with their terms
+func (h *AcademicCalendarHandler) ListYears(c *gin.Context) {
+	schoolID := c.GetString("tenant_school_id")
+
+	var years []models.AcademicYear
+	query := h.db.Preload("Terms", func(db *gorm.DB) *gorm.DB { return db.Order("start_date") })
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+	if err := query.Order("year DESC").Find(&years).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, years)
+}
+
+// CreateYear defines an academic year and its terms for the school
+func (h *AcademicCalendarHandler) CreateYear(c *gin.Context) {
+	var req struct {
+		SchoolID  string               `json:"school_id"`
+		Year      int                  `json:"year" binding:"required"`
+		StartDate time.Time            `json:"start_date" binding:"required"`
+		EndDate   time.Time            `json:"end_date" binding:"required"`
+		Terms     []services.TermInput `json:"terms" binding:"required,min=1,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schoolID := c.GetString("tenant_school_id")
+	if schoolID == "" {
+		// System admins define the calendar of the school they name
+		schoolID = req.SchoolID
+	}
+	parsedSchoolID, err := uuid.Parse(schoolID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "School ID required"})
+		return
+	}
+
+	year, err := services.NewAcademicCalendarService(h.db.WithContext(c)).CreateYear(parsedSchoolID, req.Year, req.StartDate, req.EndDate, req.Terms)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, year)
+}
+
+// CurrentTerm returns the school's term in session today
+func (h *AcademicCalendarHandler) CurrentTerm(c *gin.Context) {
+	schoolID, err := uuid.Parse(c.GetString("tenant_school_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "School ID required"})
+		return
+	}
+
+	term, err := h.calendarService.CurrentTerm(schoolID, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrNoCalendar) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, term)
+}
+
+// defaultTerm fills in the school's current term when a list request gives
+// neither term nor year. Pass all=true to list every term.
+func defaultTerm(c *gin.Context, calendar *services.AcademicCalendarService, term, year string) (string, string) {
+	if term != "" || year != "" || c.Query("all") == "true" {
+		return term, year
+	}
+	schoolID, err := uuid.Parse(c.GetString("tenant_school_id"))
+	if err != nil {
+		return term, year
+	}
+	current, err := calendar.CurrentTerm(schoolID, time.Now())
+	if err != nil {
+		return term, year
+	}
+	return current.Name, strconv.Itoa(current.Year)
+}
+
+// rejectUndefinedTerm writes a 400 response and returns true if the school has
+// not defined the term
+func rejectUndefinedTerm(c *gin.Context, calendar *services.AcademicCalendarService, schoolID uuid.UUID, term string, year int) bool {
+	err := calendar.ValidateTerm(schoolID, term, year)
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, services.ErrUndefinedTerm):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return true
+}
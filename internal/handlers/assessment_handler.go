@@ -0,0 +1,629 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"github.com/school-system/backend/internal/services"
+	"github.com/school-system/backend/internal/spreadsheet"
+	"gorm.io/gorm"
+)
+
+// Assessment types understood by the result computation
+var validAssessmentTypes = map[string]bool{
+	"ca":   true,
+	"exam": true,
+}
+
+type AssessmentHandler struct {
+	db                *gorm.DB
+	lockService       *services.TermLockService
+	calendarService   *services.AcademicCalendarService
+	assignmentService *services.TeacherAssignmentService
+}
+
+func NewAssessmentHandler(db *gorm.DB) *AssessmentHandler {
+	return &AssessmentHandler{
+		db:                db,
+		lockService:       services.NewTermLockService(db),
+		calendarService:   services.NewAcademicCalendarService(db),
+		assignmentService: services.NewTeacherAssignmentService(db),
+	}
+}
+
+type MarkEntry struct {
+	StudentID      string  `json:"student_id" binding:"required"`
+	MarksObtained  float64 `json:"marks_obtained"`
+	TeacherComment string  `json:"teacher_comment"`
+}
+
+func (h *AssessmentHandler) List(c *gin.Context) {
+	schoolID := c.GetString("tenant_school_id")
+
+	var assessments []models.Assessment
+	query := h.db.Preload("StandardSubject").Preload("Class")
+
+	// Filter by school for non-system admins
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+
+	if classID := c.Query("class_id"); classID != "" {
+		query = query.Where("class_id = ?", classID)
+	}
+	if subjectID := c.Query("subject_id"); subjectID != "" {
+		query = query.Where("subject_id = ?", subjectID)
+	}
+	// Default to the current term unless a class is given
+	term, year := c.Query("term"), c.Query("year")
+	if c.Query("class_id") == "" {
+		term, year = defaultTerm(c, h.calendarService, term, year)
+	}
+	if term != "" {
+		query = query.Where("term = ?", term)
+	}
+	if year != "" {
+		query = query.Where("year = ?", year)
+	}
+
+	if err := query.Order("date DESC").Find(&assessments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, assessments)
+}
+
+func (h *AssessmentHandler) Create(c *gin.Context) {
+	var req struct {
+		ClassID        string       `json:"class_id" binding:"required"`
+		SubjectID      string       `json:"subject_id" binding:"required"`
+		AssessmentType string       `json:"assessment_type" binding:"required"`
+		MaxMarks       int          `json:"max_marks" binding:"required,min=1"`
+		Date           string       `json:"date"`
+		Paper          int          `json:"paper"`
+		Meta           models.JSONB `json:"meta"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !validAssessmentTypes[req.AssessmentType] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assessment type - must be 'ca' or 'exam'"})
+		return
+	}
+
+	schoolID := c.GetString("tenant_school_id")
+
+	// Verify class belongs to the same school
+	var class models.Class
+	query := h.db.Where("id = ?", req.ClassID)
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+	if err := query.First(&class).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Class not found or access denied"})
+		return
+	}
+	if rejectLockedTerm(c, h.lockService, class.SchoolID, class.Term, class.Year) {
+		return
+	}
+
+	// Verify that the subject is a standard subject taught at this level
+	var standardSubject models.StandardSubject
+	if err := h.db.First(&standardSubject, "id = ?", req.SubjectID).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subject - only standard curriculum subjects are allowed"})
+		return
+	}
+	if standardSubject.Level != class.Level {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Subject is not offered at the class level"})
+		return
+	}
+	if rejectUnassigned(c, h.assignmentService, class.ID, standardSubject.ID) {
+		return
+	}
+
+	if req.Paper < 0 || req.Paper > standardSubject.Papers {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid paper - %s has %d paper(s)", standardSubject.Name, standardSubject.Papers)})
+		return
+	}
+
+	date := time.Now()
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date - expected YYYY-MM-DD"})
+			return
+		}
+		date = parsed
+	}
+
+	meta := req.Meta
+	if meta == nil {
+		meta = make(models.JSONB)
+	}
+	if req.Paper > 0 {
+		meta["paper"] = req.Paper
+	}
+
+	userID, _ := c.Get("user_id")
+	assessment := models.Assessment{
+		SchoolID:       class.SchoolID,
+		ClassID:        class.ID,
+		SubjectID:      standardSubject.ID,
+		AssessmentType: req.AssessmentType,
+		MaxMarks:       req.MaxMarks,
+		Date:           date,
+		Term:           class.Term,
+		Year:           class.Year,
+		Meta:           meta,
+		CreatedBy:      userID.(uuid.UUID),
+	}
+
+	if err := h.db.WithContext(c).Create(&assessment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, assessment)
+}
+
+func (h *AssessmentHandler) Get(c *gin.Context) {
+	assessment, ok := h.findAssessment(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, assessment)
+}
+
+func (h *AssessmentHandler) Update(c *gin.Context) {
+	assessment, ok := h.findWritableAssessment(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		AssessmentType string       `json:"assessment_type"`
+		MaxMarks       int          `json:"max_marks"`
+		Date           string       `json:"date"`
+		Meta           models.JSONB `json:"meta"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.AssessmentType != "" {
+		if !validAssessmentTypes[req.AssessmentType] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assessment type - must be 'ca' or 'exam'"})
+			return
+		}
+		assessment.AssessmentType = req.AssessmentType
+	}
+
+	if req.MaxMarks > 0 {
+		// Existing marks must still fit within the new maximum
+		var highest float64
+		h.db.Model(&models.Mark{}).Where("assessment_id = ?", assessment.ID).
+			Select("COALESCE(MAX(marks_obtained), 0)").Scan(&highest)
+		if highest > float64(req.MaxMarks) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Max marks cannot be lower than the highest recorded mark (%.2f)", highest)})
+			return
+		}
+		assessment.MaxMarks = req.MaxMarks
+	}
+
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date - expected YYYY-MM-DD"})
+			return
+		}
+		assessment.Date = parsed
+	}
+
+	if req.Meta != nil {
+		// The paper decides how exam marks are graded, so it is fixed once set
+		if paper, ok := req.Meta["paper"]; ok && !reflect.DeepEqual(paper, assessment.Meta["paper"]) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "The paper cannot be changed - create a new assessment instead"})
+			return
+		}
+		// Keys are merged into the existing meta; a null value removes one
+		if assessment.Meta == nil {
+			assessment.Meta = make(models.JSONB)
+		}
+		for key, value := range req.Meta {
+			if value == nil {
+				delete(assessment.Meta, key)
+			} else {
+				assessment.Meta[key] = value
+			}
+		}
+	}
+
+	if err := h.db.WithContext(c).Save(assessment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, assessment)
+}
+
+func (h *AssessmentHandler) Delete(c *gin.Context) {
+	assessment, ok := h.findWritableAssessment(c)
+	if !ok {
+		return
+	}
+
+	err := h.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("assessment_id = ?", assessment.ID).Delete(&models.Mark{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(assessment).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Assessment deleted"})
+}
+
+func (h *AssessmentHandler) GetMarks(c *gin.Context) {
+	assessment, ok := h.findAssessment(c)
+	if !ok {
+		return
+	}
+
+	var marks []models.Mark
+	if err := h.db.Preload("Student").Where("assessment_id = ?", assessment.ID).Find(&marks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, marks)
+}
+
+// EnterMarks creates or updates marks for one or more students in an assessment
+func (h *AssessmentHandler) EnterMarks(c *gin.Context) {
+	assessment, ok := h.findWritableAssessment(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Marks []MarkEntry `json:"marks" binding:"required,min=1,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	marks, err := saveMarks(h.db.WithContext(c), assessment, req.Marks, userID.(uuid.UUID))
+	if err != nil {
+		var entryErr *markEntryError
+		if errors.As(err, &entryErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": entryErr.Error(), "student_id": entryErr.StudentID})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, marks)
+}
+
+// markSheetStudent is an enrolled student listed on a mark sheet
+type markSheetStudent struct {
+	ID          uuid.UUID
+	AdmissionNo string
+	FirstName   string
+	LastName    string
+}
+
+// markSheetStudents returns the students enrolled in the assessment's class
+func (h *AssessmentHandler) markSheetStudents(assessment *models.Assessment) ([]markSheetStudent, error) {
+	var students []markSheetStudent
+	err := h.db.Table("students").
+		Select("DISTINCT students.id, students.admission_no, students.first_name, students.last_name").
+		Joins("JOIN enrollments ON enrollments.student_id = students.id AND enrollments.deleted_at IS NULL").
+		Where("enrollments.class_id = ? AND students.deleted_at IS NULL", assessment.ClassID).
+		Order("students.admission_no").
+		Scan(&students).Error
+	return students, err
+}
+
+// GetMarkSheet downloads a mark sheet for the assessment listing every enrolled
+// student, pre-filled with any marks already entered (format=xlsx or csv)
+func (h *AssessmentHandler) GetMarkSheet(c *gin.Context) {
+	assessment, ok := h.findAssessment(c)
+	if !ok {
+		return
+	}
+
+	format := c.DefaultQuery("format", spreadsheet.FormatXLSX)
+	if format != spreadsheet.FormatXLSX && format != spreadsheet.FormatCSV {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format - must be 'xlsx' or 'csv'"})
+		return
+	}
+
+	var class models.Class
+	if err := h.db.First(&class, "id = ?", assessment.ClassID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+		return
+	}
+
+	students, err := h.markSheetStudents(assessment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var marks []models.Mark
+	h.db.Where("assessment_id = ?", assessment.ID).Find(&marks)
+	existing := make(map[uuid.UUID]models.Mark, len(marks))
+	for _, mark := range marks {
+		existing[mark.StudentID] = mark
+	}
+
+	rows := [][]string{{"Admission No", "First Name", "Last Name", fmt.Sprintf("Marks (out of %d)", assessment.MaxMarks), "Comment"}}
+	for _, student := range students {
+		row := []string{student.AdmissionNo, student.FirstName, student.LastName, "", ""}
+		if mark, ok := existing[student.ID]; ok {
+			row[3] = strconv.FormatFloat(mark.MarksObtained, 'f', -1, 64)
+			row[4] = mark.TeacherComment
+		}
+		rows = append(rows, row)
+	}
+
+	subjectCode := "subject"
+	if assessment.StandardSubject != nil {
+		subjectCode = assessment.StandardSubject.Code
+	}
+	name := fmt.Sprintf("marks-%s-%s-%s-%s-%d", class.Level, subjectCode, assessment.AssessmentType, assessment.Term, assessment.Year)
+
+	var buf bytes.Buffer
+	if err := spreadsheet.Write(&buf, format, name, rows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", strings.ReplaceAll(name, " ", "-")+"."+format))
+	c.Data(http.StatusOK, spreadsheet.ContentType(format), buf.Bytes())
+}
+
+// markSheetRowError describes a problem with one row of an uploaded mark sheet
+type markSheetRowError struct {
+	Row         int    `json:"row"`
+	AdmissionNo string `json:"admission_no,omitempty"`
+	Error       string `json:"error"`
+}
+
+// UploadMarkSheet reads a filled mark sheet and saves every mark in one
+// transaction. Rows with a blank mark are skipped; if any row is invalid or
+// names an unknown admission number, nothing is saved.
+func (h *AssessmentHandler) UploadMarkSheet(c *gin.Context) {
+	assessment, ok := h.findWritableAssessment(c)
+	if !ok {
+		return
+	}
+
+	rows, ok := readUploadedSheet(c)
+	if !ok {
+		return
+	}
+	if len(rows) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "File is empty"})
+		return
+	}
+
+	admissionCol, marksCol, commentCol := -1, -1, -1
+	for i, header := range rows[0] {
+		header = strings.ToLower(header)
+		switch {
+		case strings.HasPrefix(header, "admission") && admissionCol < 0:
+			admissionCol = i
+		case strings.HasPrefix(header, "mark") && marksCol < 0:
+			marksCol = i
+		case strings.HasPrefix(header, "comment") && commentCol < 0:
+			commentCol = i
+		}
+	}
+	if admissionCol < 0 || marksCol < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Mark sheet must have 'Admission No' and 'Marks' columns"})
+		return
+	}
+
+	students, err := h.markSheetStudents(assessment)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	enrolled := make(map[string]uuid.UUID, len(students))
+	for _, student := range students {
+		enrolled[strings.ToUpper(student.AdmissionNo)] = student.ID
+	}
+
+	cell := func(row []string, col int) string {
+		if col < 0 || col >= len(row) {
+			return ""
+		}
+		return row[col]
+	}
+
+	var entries []MarkEntry
+	var rowErrors []markSheetRowError
+	var unknown []string
+	seen := make(map[string]int)
+	for i, row := range rows[1:] {
+		line := i + 2
+		admissionNo := cell(row, admissionCol)
+		value := cell(row, marksCol)
+		if admissionNo == "" {
+			if value != "" {
+				rowErrors = append(rowErrors, markSheetRowError{Row: line, Error: "Admission number is missing"})
+			}
+			continue
+		}
+
+		studentID, ok := enrolled[strings.ToUpper(admissionNo)]
+		if !ok {
+			unknown = append(unknown, admissionNo)
+			rowErrors = append(rowErrors, markSheetRowError{Row: line, AdmissionNo: admissionNo, Error: "Admission number is not enrolled in the assessment's class"})
+			continue
+		}
+		if first, dup := seen[strings.ToUpper(admissionNo)]; dup {
+			rowErrors = append(rowErrors, markSheetRowError{Row: line, AdmissionNo: admissionNo, Error: fmt.Sprintf("Duplicate of row %d", first)})
+			continue
+		}
+		seen[strings.ToUpper(admissionNo)] = line
+
+		if value == "" {
+			continue
+		}
+		marks, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			rowErrors = append(rowErrors, markSheetRowError{Row: line, AdmissionNo: admissionNo, Error: fmt.Sprintf("Marks %q is not a number", value)})
+			continue
+		}
+		if marks < 0 || marks > float64(assessment.MaxMarks) {
+			rowErrors = append(rowErrors, markSheetRowError{Row: line, AdmissionNo: admissionNo, Error: fmt.Sprintf("Marks must be between 0 and %d", assessment.MaxMarks)})
+			continue
+		}
+
+		entries = append(entries, MarkEntry{
+			StudentID:      studentID.String(),
+			MarksObtained:  marks,
+			TeacherComment: cell(row, commentCol),
+		})
+	}
+
+	if len(rowErrors) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":                     "Mark sheet has errors - no marks were saved",
+			"errors":                    rowErrors,
+			"unknown_admission_numbers": unknown,
+		})
+		return
+	}
+	if len(entries) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Mark sheet has no marks"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	marks, err := saveMarks(h.db.WithContext(c), assessment, entries, userID.(uuid.UUID))
+	if err != nil {
+		var entryErr *markEntryError
+		if errors.As(err, &entryErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": entryErr.Error(), "student_id": entryErr.StudentID})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"saved": len(marks), "marks": marks})
+}
+
+// findAssessment loads the assessment from the :id param, scoped to the tenant school
+func (h *AssessmentHandler) findAssessment(c *gin.Context) (*models.Assessment, bool) {
+	schoolID := c.GetString("tenant_school_id")
+
+	var assessment models.Assessment
+	query := h.db.Preload("StandardSubject").Where("id = ?", c.Param("id"))
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+	if err := query.First(&assessment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assessment not found"})
+		return nil, false
+	}
+	return &assessment, true
+}
+
+// findWritableAssessment is findAssessment for requests that change marks,
+// rejecting teachers not assigned to the subject and assessments in a locked term
+func (h *AssessmentHandler) findWritableAssessment(c *gin.Context) (*models.Assessment, bool) {
+	assessment, ok := h.findAssessment(c)
+	if !ok || rejectUnassigned(c, h.assignmentService, assessment.ClassID, assessment.SubjectID) ||
+		rejectLockedTerm(c, h.lockService, assessment.SchoolID, assessment.Term, assessment.Year) {
+		return nil, false
+	}
+	return assessment, true
+}
+
+type markEntryError struct {
+	StudentID string
+	Message   string
+}
+
+func (e *markEntryError) Error() string {
+	return e.Message
+}
+
+// saveMarks validates and upserts marks for an assessment in a single transaction
+func saveMarks(db *gorm.DB, assessment *models.Assessment, entries []MarkEntry, enteredBy uuid.UUID) ([]models.Mark, error) {
+	saved := make([]models.Mark, 0, len(entries))
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, entry := range entries {
+			studentID, err := uuid.Parse(entry.StudentID)
+			if err != nil {
+				return &markEntryError{StudentID: entry.StudentID, Message: "Invalid student ID"}
+			}
+
+			if entry.MarksObtained < 0 || entry.MarksObtained > float64(assessment.MaxMarks) {
+				return &markEntryError{
+					StudentID: entry.StudentID,
+					Message:   fmt.Sprintf("Marks must be between 0 and %d", assessment.MaxMarks),
+				}
+			}
+
+			// Student must be enrolled in the assessment's class
+			var count int64
+			tx.Model(&models.Enrollment{}).Where("student_id = ? AND class_id = ?", studentID, assessment.ClassID).Count(&count)
+			if count == 0 {
+				return &markEntryError{StudentID: entry.StudentID, Message: "Student is not enrolled in the assessment's class"}
+			}
+
+			var mark models.Mark
+			err = tx.Where("assessment_id = ? AND student_id = ?", assessment.ID, studentID).First(&mark).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			mark.AssessmentID = assessment.ID
+			mark.StudentID = studentID
+			mark.MarksObtained = entry.MarksObtained
+			mark.TeacherComment = entry.TeacherComment
+			mark.EnteredBy = enteredBy
+			mark.EnteredAt = time.Now()
+
+			if err := tx.Save(&mark).Error; err != nil {
+				return err
+			}
+			saved = append(saved, mark)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return saved, nil
+}
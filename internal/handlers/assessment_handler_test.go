@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+)
+
+func TestAssessmentUpdate_MergesMeta(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.StandardSubject{}, &models.Assessment{}, &models.Mark{}, &models.TermLock{})
+
+	subject := models.StandardSubject{Name: "Physics", Code: "PHY", Level: "S5", Papers: 3}
+	if err := db.Create(&subject).Error; err != nil {
+		t.Fatalf("Failed to create subject: %v", err)
+	}
+	assessment := models.Assessment{
+		SchoolID:       uuid.New(),
+		ClassID:        uuid.New(),
+		SubjectID:      subject.ID,
+		AssessmentType: "exam",
+		MaxMarks:       100,
+		Term:           "Term1",
+		Year:           2026,
+		Meta:           models.JSONB{"paper": 2, "title": "Mock", "room": "Lab 1"},
+		CreatedBy:      uuid.New(),
+	}
+	if err := db.Create(&assessment).Error; err != nil {
+		t.Fatalf("Failed to create assessment: %v", err)
+	}
+
+	handler := NewAssessmentHandler(db)
+	update := func(body string) int {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPut, "/assessments/"+assessment.ID.String(), bytes.NewBufferString(body))
+		c.Request.Header.Set("Content-Type", "application/json")
+		c.Params = gin.Params{{Key: "id", Value: assessment.ID.String()}}
+		c.Set("user_id", uuid.New())
+		c.Set("user_role", "school_admin")
+		handler.Update(c)
+		return w.Code
+	}
+	stored := func() models.JSONB {
+		var current models.Assessment
+		if err := db.First(&current, "id = ?", assessment.ID).Error; err != nil {
+			t.Fatalf("Failed to load assessment: %v", err)
+		}
+		return current.Meta
+	}
+
+	if code := update(`{"meta": {"title": "End of term", "room": null, "invigilator": "Mr Okello"}}`); code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", code)
+	}
+	meta := stored()
+	if meta["paper"] != float64(2) || meta["title"] != "End of term" || meta["invigilator"] != "Mr Okello" {
+		t.Errorf("Expected the keys merged with the paper kept, got %v", meta)
+	}
+	if _, ok := meta["room"]; ok {
+		t.Errorf("Expected a null value to remove the key, got %v", meta)
+	}
+
+	// Repeating the paper is fine; changing or removing it is not
+	if code := update(`{"meta": {"paper": 2, "title": "Mock"}}`); code != http.StatusOK {
+		t.Errorf("Expected the same paper accepted, got %d", code)
+	}
+	for _, body := range []string{`{"meta": {"paper": 1}}`, `{"meta": {"paper": null}}`} {
+		if code := update(body); code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, code)
+		}
+	}
+	if meta := stored(); meta["paper"] != float64(2) {
+		t.Errorf("Expected paper 2 kept, got %v", meta["paper"])
+	}
+}
@@ -1,20 +1,28 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/school-system/backend/internal/models"
+	"github.com/school-system/backend/internal/services"
 	"gorm.io/gorm"
 )
 
 type AuditHandler struct {
-	db *gorm.DB
+	db           *gorm.DB
+	auditService *services.AuditService
 }
 
 func NewAuditHandler(db *gorm.DB) *AuditHandler {
-	return &AuditHandler{db: db}
+	return &AuditHandler{
+		db:           db,
+		auditService: services.NewAuditService(db),
+	}
 }
 
 func (h *AuditHandler) GetRecentActivity(c *gin.Context) {
@@ -43,4 +51,140 @@ func (h *AuditHandler) GetRecentActivity(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, activities)
-}
\ No newline at end of file
+}
+
+// Search lists audit entries, newest first. Filters: actor_id, resource_type,
+// resource_id, action, from and to (RFC 3339 or YYYY-MM-DD, a date-only to
+// taking in the whole of that day), plus limit and the cursor returned with the
+// previous page. School admins only see their own
+// school's trail; system admins may narrow to one with school_id.
+func (h *AuditHandler) Search(c *gin.Context) {
+	var filter services.AuditFilter
+
+	schoolID := c.GetString("tenant_school_id")
+	if schoolID == "" {
+		schoolID = c.Query("school_id")
+	}
+	var ok bool
+	if filter.SchoolID, ok = optionalUUID(c, schoolID, "school_id"); !ok {
+		return
+	}
+	if filter.ActorID, ok = optionalUUID(c, c.Query("actor_id"), "actor_id"); !ok {
+		return
+	}
+	if filter.ResourceID, ok = optionalUUID(c, c.Query("resource_id"), "resource_id"); !ok {
+		return
+	}
+	if filter.From, ok = optionalTime(c, "from", false); !ok {
+		return
+	}
+	if filter.To, ok = optionalTime(c, "to", true); !ok {
+		return
+	}
+
+	filter.ResourceType = c.Query("resource_type")
+	filter.Action = c.Query("action")
+	filter.Cursor = c.Query("cursor")
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+
+	page, err := h.auditService.Search(filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCursor) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+// VerifyChain reports whether the audit log's hash chain is intact and, if
+// not, the first entry where it breaks
+func (h *AuditHandler) VerifyChain(c *gin.Context) {
+	report, err := h.auditService.VerifyChain()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// StudentHistory returns the field-level change timeline of a student
+func (h *AuditHandler) StudentHistory(c *gin.Context) {
+	h.history(c, &models.Student{}, "student")
+}
+
+// ResultHistory returns the field-level change timeline of a subject result
+func (h *AuditHandler) ResultHistory(c *gin.Context) {
+	h.history(c, &models.SubjectResult{}, "subject_result")
+}
+
+// history checks the resource belongs to the tenant school, even if since
+// deleted, before returning its timeline
+func (h *AuditHandler) history(c *gin.Context, model interface{}, resourceType string) {
+	resourceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	var scope *uuid.UUID
+	if schoolID := c.GetString("tenant_school_id"); schoolID != "" {
+		parsed, _ := uuid.Parse(schoolID)
+		scope = &parsed
+
+		var count int64
+		h.db.Unscoped().Model(model).Where("id = ? AND school_id = ?", resourceID, parsed).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+			return
+		}
+	}
+
+	history, err := h.auditService.History(resourceType, resourceID, scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
+
+// optionalUUID parses an optional ID filter, writing a 400 response and
+// returning false if it is malformed
+func optionalUUID(c *gin.Context, value, name string) (*uuid.UUID, bool) {
+	if value == "" {
+		return nil, true
+	}
+	parsed, err := uuid.Parse(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return nil, false
+	}
+	return &parsed, true
+}
+
+// optionalTime parses an optional RFC 3339 or YYYY-MM-DD query parameter,
+// writing a 400 response and returning false if it is malformed. With
+// endOfDay, a bare date gives the start of the next day, so an exclusive upper
+// bound still covers the date named.
+func optionalTime(c *gin.Context, name string, endOfDay bool) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		if parsed, err = time.Parse("2006-01-02", value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + " - use RFC 3339 or YYYY-MM-DD"})
+			return nil, false
+		}
+		if endOfDay {
+			parsed = parsed.AddDate(0, 0, 1)
+		}
+	}
+	return &parsed, true
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"github.com/school-system/backend/internal/services"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	// Every connection to :memory: is a separate database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	return db
+}
+
+func TestAuditSearch_DateOnlyToIncludesDay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t, &models.User{}, &models.AuditLog{})
+	schoolID := uuid.New()
+
+	for _, at := range []string{"2026-03-30T23:00:00Z", "2026-03-31T00:00:00Z", "2026-03-31T23:59:59Z", "2026-04-01T00:00:00Z"} {
+		timestamp, _ := time.Parse(time.RFC3339, at)
+		log := models.AuditLog{Action: "UPDATE", ResourceType: "student", SchoolID: &schoolID, Timestamp: timestamp}
+		if err := db.Create(&log).Error; err != nil {
+			t.Fatalf("Failed to create audit log: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query    string
+		expected int
+	}{
+		{"from=2026-03-31&to=2026-03-31", 2},
+		{"to=2026-03-31", 3},
+		{"from=2026-03-31", 3},
+		// A full timestamp is used as given
+		{"to=2026-03-31T23:59:59Z", 2},
+	}
+
+	handler := NewAuditHandler(db)
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/audit?"+tt.query, nil)
+		c.Set("tenant_school_id", schoolID.String())
+
+		handler.Search(c)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tt.query, w.Code, w.Body.String())
+		}
+		var page services.AuditPage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatalf("%s: failed to decode response: %v", tt.query, err)
+		}
+		if len(page.Entries) != tt.expected {
+			t.Errorf("%s: expected %d entries, got %d", tt.query, tt.expected, len(page.Entries))
+		}
+	}
+}
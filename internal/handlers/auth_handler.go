@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/school-system/backend/internal/services"
 )
 
@@ -24,6 +26,11 @@ type RefreshRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
 // @Summary Login
 // @Tags auth
 // @Accept json
@@ -45,11 +52,12 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	userResponse := gin.H{
-		"id":        user.ID,
-		"email":     user.Email,
-		"full_name": user.FullName,
-		"role":      user.Role,
-		"school_id": user.SchoolID,
+		"id":                   user.ID,
+		"email":                user.Email,
+		"full_name":            user.FullName,
+		"role":                 user.Role,
+		"school_id":            user.SchoolID,
+		"must_change_password": user.MustChangePassword,
 	}
 
 	// Include school details if user belongs to a school
@@ -71,6 +79,45 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
+// @Summary Change password
+// @Description Replaces the signed-in user's password. Users with a one-time
+// @Description password must call this, with the restricted token login
+// @Description returned, before any other endpoint.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} services.TokenPair
+// @Router /api/v1/auth/change-password [post]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	tokens, _, err := h.authService.WithContext(c).ChangePassword(userID.(uuid.UUID), req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidCredentials):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		case errors.Is(err, services.ErrWeakPassword), errors.Is(err, services.ErrPasswordReused):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, services.ErrUserNotActive):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Password changed successfully",
+		"tokens":  tokens,
+	})
+}
+
 // @Summary Refresh tokens
 // @Tags auth
 // @Accept json
@@ -1,26 +1,42 @@
 package handlers
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/school-system/backend/internal/models"
+	"github.com/school-system/backend/internal/services"
+	"github.com/school-system/backend/internal/spreadsheet"
 	"gorm.io/gorm"
 )
 
 type ClassHandler struct {
-	db *gorm.DB
+	db                *gorm.DB
+	rankingService    *services.RankingService
+	broadsheetService *services.BroadsheetService
+	lockService       *services.TermLockService
+	calendarService   *services.AcademicCalendarService
 }
 
 func NewClassHandler(db *gorm.DB) *ClassHandler {
-	return &ClassHandler{db: db}
+	return &ClassHandler{
+		db:                db,
+		rankingService:    services.NewRankingService(db),
+		broadsheetService: services.NewBroadsheetService(db),
+		lockService:       services.NewTermLockService(db),
+		calendarService:   services.NewAcademicCalendarService(db),
+	}
 }
 
+// List returns the school's classes, defaulting to the current term
 func (h *ClassHandler) List(c *gin.Context) {
-	year := c.Query("year")
-	term := c.Query("term")
+	term, year := defaultTerm(c, h.calendarService, c.Query("term"), c.Query("year"))
 	schoolID := c.GetString("tenant_school_id")
 
 	var classes []models.Class
@@ -37,13 +53,52 @@ func (h *ClassHandler) List(c *gin.Context) {
 	if term != "" {
 		query = query.Where("term = ?", term)
 	}
+	if level := c.Query("level"); level != "" {
+		query = query.Where("level = ?", level)
+	}
+	if stream, ok := c.GetQuery("stream"); ok {
+		query = query.Where("stream = ?", stream)
+	}
 
-	if err := query.Find(&classes).Error; err != nil {
+	if err := query.Order("level, stream").Find(&classes).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, classes)
+	withLocks, err := h.withLockStatus(classes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, withLocks)
+}
+
+// ClassWithLock is a class with whether its term's results are locked
+type ClassWithLock struct {
+	models.Class
+	Locked bool `json:"locked"`
+}
+
+func (h *ClassHandler) withLockStatus(classes []models.Class) ([]ClassWithLock, error) {
+	result := make([]ClassWithLock, len(classes))
+	if len(classes) == 0 {
+		return result, nil
+	}
+
+	schoolIDs := make([]uuid.UUID, 0, len(classes))
+	for _, class := range classes {
+		schoolIDs = append(schoolIDs, class.SchoolID)
+	}
+	locked, err := h.lockService.LockedTerms(schoolIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, class := range classes {
+		result[i] = ClassWithLock{Class: class, Locked: locked[services.TermKey(class.SchoolID, class.Term, class.Year)]}
+	}
+	return result, nil
 }
 
 func (h *ClassHandler) Create(c *gin.Context) {
@@ -65,7 +120,7 @@ func (h *ClassHandler) Create(c *gin.Context) {
 		class.SchoolID = schoolID
 	}
 
-	if err := h.db.Create(&class).Error; err != nil {
+	if err := h.db.WithContext(c).Create(&class).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -80,7 +135,13 @@ func (h *ClassHandler) Get(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
 		return
 	}
-	c.JSON(http.StatusOK, class)
+
+	withLocks, err := h.withLockStatus([]models.Class{class})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, withLocks[0])
 }
 
 func (h *ClassHandler) GetStudents(c *gin.Context) {
@@ -132,3 +193,153 @@ func (h *ClassHandler) GetLevels(c *gin.Context) {
 
 	c.JSON(http.StatusOK, levels)
 }
+
+// GetRankings returns overall and per-subject positions for the class's term.
+// The optional tie_break query ("shared" or "dense") overrides the school setting;
+// scope=level ranks every stream of the class's level together.
+func (h *ClassHandler) GetRankings(c *gin.Context) {
+	schoolID := c.GetString("tenant_school_id")
+
+	var class models.Class
+	query := h.db.Where("id = ?", c.Param("id"))
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+	if err := query.First(&class).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+		return
+	}
+
+	tieBreak, err := h.rankingService.ResolveTieBreak(class.SchoolID, c.Query("tie_break"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var ranking *services.ClassRanking
+	switch c.DefaultQuery("scope", services.RankingScopeClass) {
+	case services.RankingScopeClass:
+		ranking, err = h.rankingService.RankClass(&class, tieBreak)
+	case services.RankingScopeLevel:
+		ranking, err = h.rankingService.RankLevel(&class, tieBreak)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope - must be 'class' or 'level'"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ranking)
+}
+
+// GetBroadsheet exports the class broadsheet as CSV or XLSX. Term and year
+// default to the class's own; scope=level covers every stream of the level.
+func (h *ClassHandler) GetBroadsheet(c *gin.Context) {
+	schoolID := c.GetString("tenant_school_id")
+
+	var class models.Class
+	query := h.db.Where("id = ?", c.Param("id"))
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+	if err := query.First(&class).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+		return
+	}
+
+	format := c.DefaultQuery("format", spreadsheet.FormatCSV)
+	if format != spreadsheet.FormatCSV && format != spreadsheet.FormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format - must be 'csv' or 'xlsx'"})
+		return
+	}
+
+	scope := c.DefaultQuery("scope", services.RankingScopeClass)
+	if scope != services.RankingScopeClass && scope != services.RankingScopeLevel {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope - must be 'class' or 'level'"})
+		return
+	}
+
+	term := c.DefaultQuery("term", class.Term)
+	year := class.Year
+	if y := c.Query("year"); y != "" {
+		parsed, err := strconv.Atoi(y)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+		year = parsed
+	}
+
+	sheet, err := h.broadsheetService.Build(&class, term, year, scope)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	name := fmt.Sprintf("broadsheet-%s-%s-%d", class.Level, term, year)
+	if scope == services.RankingScopeClass && class.Stream != "" {
+		name = fmt.Sprintf("broadsheet-%s-%s-%s-%d", class.Level, class.Stream, term, year)
+	}
+	var buf bytes.Buffer
+	if err := spreadsheet.Write(&buf, format, name, sheet.Table()); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+"."+format))
+	c.Data(http.StatusOK, spreadsheet.ContentType(format), buf.Bytes())
+}
+
+// AddStream adds a named stream to a level, e.g. "Blue" for P5, with its own
+// class for each term of the year and an optional class teacher
+func (h *ClassHandler) AddStream(c *gin.Context) {
+	var req struct {
+		SchoolID  string     `json:"school_id"`
+		Level     string     `json:"level" binding:"required"`
+		Stream    string     `json:"stream" binding:"required"`
+		TeacherID *uuid.UUID `json:"teacher_id"`
+		Year      int        `json:"year"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schoolID := c.GetString("tenant_school_id")
+	if schoolID == "" {
+		// System admins add streams to the school they name
+		schoolID = req.SchoolID
+	}
+	parsedSchoolID, err := uuid.Parse(schoolID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "School ID required"})
+		return
+	}
+
+	if req.TeacherID != nil {
+		var teacher models.User
+		if err := h.db.Where("id = ? AND school_id = ?", *req.TeacherID, parsedSchoolID).First(&teacher).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Teacher not found in this school"})
+			return
+		}
+	}
+
+	year := req.Year
+	if year == 0 {
+		year = time.Now().Year()
+	}
+
+	classes, err := services.NewSchoolSetupService(h.db.WithContext(c)).AddStream(parsedSchoolID, req.Level, req.Stream, req.TeacherID, year)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStream) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, classes)
+}
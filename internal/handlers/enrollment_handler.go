@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/services"
+	"gorm.io/gorm"
+)
+
+type EnrollmentHandler struct {
+	db *gorm.DB
+}
+
+func NewEnrollmentHandler(db *gorm.DB) *EnrollmentHandler {
+	return &EnrollmentHandler{
+		db: db,
+	}
+}
+
+// CarryOver copies the school's active enrollments for a term into the next
+// term's classes. Set dry_run to see the counts without enrolling anyone.
+func (h *EnrollmentHandler) CarryOver(c *gin.Context) {
+	var req struct {
+		SchoolID string `json:"school_id"`
+		Term     string `json:"term" binding:"required"`
+		Year     int    `json:"year" binding:"required"`
+		DryRun   bool   `json:"dry_run"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schoolID := c.GetString("tenant_school_id")
+	if schoolID == "" {
+		// System admins carry over the school they name
+		schoolID = req.SchoolID
+	}
+	parsedSchoolID, err := uuid.Parse(schoolID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "School ID required"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	report, err := services.NewEnrollmentService(h.db.WithContext(c)).CarryOver(parsedSchoolID, req.Term, req.Year, req.DryRun, userID.(uuid.UUID), c.ClientIP())
+	if err != nil {
+		if errors.Is(err, services.ErrUndefinedTerm) || errors.Is(err, services.ErrLastTerm) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/grading"
+	"github.com/school-system/backend/internal/models"
+	"github.com/school-system/backend/internal/services"
+	"gorm.io/gorm"
+)
+
+type GradingRuleHandler struct {
+	db          *gorm.DB
+	ruleService *services.GradingRuleService
+}
+
+func NewGradingRuleHandler(db *gorm.DB) *GradingRuleHandler {
+	return &GradingRuleHandler{
+		db:          db,
+		ruleService: services.NewGradingRuleService(db),
+	}
+}
+
+// List returns the grading rules of the user's school (system admins pass ?school_id=)
+func (h *GradingRuleHandler) List(c *gin.Context) {
+	schoolID, ok := ruleSchoolID(c)
+	if !ok {
+		return
+	}
+
+	rules, err := h.ruleService.GetSchoolRules(schoolID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// Update replaces a level's grading rule after validating its schema
+func (h *GradingRuleHandler) Update(c *gin.Context) {
+	schoolID, ok := ruleSchoolID(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		RuleVersion string       `json:"rule_version" binding:"required"`
+		Rules       models.JSONB `json:"rules" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := services.NewGradingRuleService(h.db.WithContext(c)).SaveRule(schoolID, c.Param("level"), req.RuleVersion, req.Rules)
+	if err != nil {
+		if errors.Is(err, grading.ErrInvalidRules) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+func ruleSchoolID(c *gin.Context) (uuid.UUID, bool) {
+	schoolIDStr := c.GetString("tenant_school_id")
+	if schoolIDStr == "" {
+		schoolIDStr = c.Query("school_id")
+	}
+
+	schoolID, err := uuid.Parse(schoolIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid school ID"})
+		return uuid.Nil, false
+	}
+	return schoolID, true
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/school-system/backend/internal/config"
+	"github.com/school-system/backend/internal/jobs"
+	"github.com/school-system/backend/internal/models"
+	"github.com/school-system/backend/internal/services"
+	"gorm.io/gorm"
+)
+
+type JobHandler struct {
+	db                *gorm.DB
+	reportCardService *services.ReportCardService
+}
+
+func NewJobHandler(db *gorm.DB, storage config.StorageConfig) *JobHandler {
+	return &JobHandler{
+		db:                db,
+		reportCardService: services.NewReportCardService(db, storage),
+	}
+}
+
+// find loads a job, hiding jobs queued for other schools
+func (h *JobHandler) find(c *gin.Context) (*models.Job, bool) {
+	schoolID := c.GetString("tenant_school_id")
+
+	var job models.Job
+	if err := h.db.First(&job, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return nil, false
+	}
+	if schoolID != "" && fmt.Sprint(job.Payload["school_id"]) != schoolID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+		return nil, false
+	}
+	return &job, true
+}
+
+// Get returns a job's status and progress
+func (h *JobHandler) Get(c *gin.Context) {
+	job, ok := h.find(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// Download streams the ZIP produced by a finished class report card job
+func (h *JobHandler) Download(c *gin.Context) {
+	job, ok := h.find(c)
+	if !ok {
+		return
+	}
+	if job.Type != services.JobTypeGenerateReportCards {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Job has no downloadable output"})
+		return
+	}
+	if job.Status != jobs.StatusCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Job is %s", job.Status)})
+		return
+	}
+
+	path := h.reportCardService.ClassArchivePath(job.ID)
+	if _, err := os.Stat(path); err != nil {
+		c.JSON(http.StatusGone, gin.H{"error": "Archive is no longer available"})
+		return
+	}
+
+	c.FileAttachment(path, fmt.Sprintf("report-cards-%s.zip", job.ID))
+}
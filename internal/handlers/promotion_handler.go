@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/services"
+	"gorm.io/gorm"
+)
+
+type PromotionHandler struct {
+	db               *gorm.DB
+	promotionService *services.PromotionService
+}
+
+func NewPromotionHandler(db *gorm.DB) *PromotionHandler {
+	return &PromotionHandler{
+		db:               db,
+		promotionService: services.NewPromotionService(db),
+	}
+}
+
+type promotionRequest struct {
+	SchoolID string `json:"school_id"`
+	Year     int    `json:"year" binding:"required"`
+	services.PromotionRules
+}
+
+// Preview shows who would be promoted, repeat or graduate at the end of a year
+func (h *PromotionHandler) Preview(c *gin.Context) {
+	req, schoolID, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	plan, err := h.promotionService.Preview(schoolID, req.Year, req.PromotionRules)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+// Commit rolls the year over: next year's classes are created and students
+// are promoted, kept back or graduated
+func (h *PromotionHandler) Commit(c *gin.Context) {
+	req, schoolID, ok := h.bind(c)
+	if !ok {
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	plan, err := services.NewPromotionService(h.db.WithContext(c)).Commit(schoolID, req.Year, req.PromotionRules, userID.(uuid.UUID), c.ClientIP())
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, plan)
+}
+
+func (h *PromotionHandler) bind(c *gin.Context) (*promotionRequest, uuid.UUID, bool) {
+	var req promotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, uuid.Nil, false
+	}
+
+	schoolID := c.GetString("tenant_school_id")
+	if schoolID == "" {
+		// System admins promote the school they name
+		schoolID = req.SchoolID
+	}
+	parsedSchoolID, err := uuid.Parse(schoolID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "School ID required"})
+		return nil, uuid.Nil, false
+	}
+	return &req, parsedSchoolID, true
+}
+
+func (h *PromotionHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrNothingToPromote):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidPassMark):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/config"
+	"github.com/school-system/backend/internal/jobs"
+	"github.com/school-system/backend/internal/models"
+	"github.com/school-system/backend/internal/services"
+	"gorm.io/gorm"
+)
+
+type ReportCardHandler struct {
+	db                *gorm.DB
+	reportCardService *services.ReportCardService
+}
+
+func NewReportCardHandler(db *gorm.DB, storage config.StorageConfig) *ReportCardHandler {
+	return &ReportCardHandler{
+		db:                db,
+		reportCardService: services.NewReportCardService(db, storage),
+	}
+}
+
+// ListByStudent returns a student's report cards, newest first
+func (h *ReportCardHandler) ListByStudent(c *gin.Context) {
+	schoolID := c.GetString("tenant_school_id")
+
+	var student models.Student
+	if err := h.db.Where("id = ? AND school_id = ?", c.Param("id"), schoolID).First(&student).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Student not found or access denied"})
+		return
+	}
+
+	var cards []models.ReportCard
+	if err := h.db.Where("student_id = ?", student.ID).Order("year DESC, term DESC").Find(&cards).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cards)
+}
+
+// Generate renders a student's report card for a term
+func (h *ReportCardHandler) Generate(c *gin.Context) {
+	schoolID := c.GetString("tenant_school_id")
+
+	var req struct {
+		Term string `json:"term" binding:"required"`
+		Year int    `json:"year" binding:"required"`
+		services.ReportCardComments
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var student models.Student
+	if err := h.db.Where("id = ? AND school_id = ?", c.Param("id"), schoolID).First(&student).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Student not found or access denied"})
+		return
+	}
+
+	var enrollment models.Enrollment
+	if err := h.db.Preload("Class").
+		Where("student_id = ? AND term = ? AND year = ?", student.ID, req.Term, req.Year).
+		Order("created_at DESC").First(&enrollment).Error; err != nil || enrollment.Class == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Student is not enrolled for %s %d", req.Term, req.Year)})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	card, err := h.reportCardService.Generate(student.ID, enrollment.Class, userID.(uuid.UUID), req.ReportCardComments, nil)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "report_card": card})
+		return
+	}
+
+	c.JSON(http.StatusCreated, card)
+}
+
+// GenerateForClass queues a background job that renders report cards for every
+// student enrolled in the class; poll GET /jobs/:id for progress
+func (h *ReportCardHandler) GenerateForClass(c *gin.Context) {
+	schoolID := c.GetString("tenant_school_id")
+
+	var class models.Class
+	query := h.db.Where("id = ?", c.Param("id"))
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+	if err := query.First(&class).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Class not found"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	job, err := jobs.Enqueue(h.db, services.JobTypeGenerateReportCards, models.JSONB{
+		"class_id":     class.ID.String(),
+		"school_id":    class.SchoolID.String(),
+		"generated_by": userID.(uuid.UUID).String(),
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, job)
+}
+
+// Download streams a generated report card PDF
+func (h *ReportCardHandler) Download(c *gin.Context) {
+	schoolID := c.GetString("tenant_school_id")
+
+	var card models.ReportCard
+	query := h.db.Joins("JOIN students ON students.id = report_cards.student_id").
+		Where("report_cards.id = ?", c.Param("id"))
+	if schoolID != "" {
+		query = query.Where("students.school_id = ?", schoolID)
+	}
+	if err := query.First(&card).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report card not found"})
+		return
+	}
+
+	path, err := h.reportCardService.FilePath(&card)
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.FileAttachment(path, fmt.Sprintf("report-card-%s-%d.pdf", card.Term, card.Year))
+}
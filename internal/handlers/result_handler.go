@@ -1,21 +1,41 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/grading"
 	"github.com/school-system/backend/internal/models"
+	"github.com/school-system/backend/internal/services"
 	"gorm.io/gorm"
 )
 
 type ResultHandler struct {
-	db *gorm.DB
+	db                 *gorm.DB
+	computationService *services.ResultComputationService
+	summaryService     *services.TermSummaryService
+	rankingService     *services.RankingService
+	lockService        *services.TermLockService
+	calendarService    *services.AcademicCalendarService
+	enrollmentService  *services.EnrollmentService
+	assignmentService  *services.TeacherAssignmentService
 }
 
 func NewResultHandler(db *gorm.DB) *ResultHandler {
-	return &ResultHandler{db: db}
+	return &ResultHandler{
+		db:                 db,
+		computationService: services.NewResultComputationService(db),
+		summaryService:     services.NewTermSummaryService(db),
+		rankingService:     services.NewRankingService(db),
+		lockService:        services.NewTermLockService(db),
+		calendarService:    services.NewAcademicCalendarService(db),
+		enrollmentService:  services.NewEnrollmentService(db),
+		assignmentService:  services.NewTeacherAssignmentService(db),
+	}
 }
 
 func (h *ResultHandler) GetByStudent(c *gin.Context) {
@@ -55,13 +75,74 @@ func (h *ResultHandler) GetByStudent(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	
+
 	c.JSON(http.StatusOK, results)
 }
 
+// GetSummariesByStudent returns a student's overall aggregates, divisions and
+// points per term, with class positions computed from each class's current
+// results
+func (h *ResultHandler) GetSummariesByStudent(c *gin.Context) {
+	studentID := c.Param("id")
+	term := c.Query("term")
+	year := c.Query("year")
+	schoolID := c.GetString("tenant_school_id")
+
+	var student models.Student
+	if err := h.db.Where("id = ? AND school_id = ?", studentID, schoolID).First(&student).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Student not found or access denied"})
+		return
+	}
+
+	var summaries []models.TermSummary
+	query := h.db.Where("student_id = ?", student.ID)
+	if term != "" {
+		query = query.Where("term = ?", term)
+	}
+	if year != "" {
+		query = query.Where("year = ?", year)
+	}
+	if err := query.Order("year DESC, term DESC").Find(&summaries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	type SummaryWithPosition struct {
+		models.TermSummary
+		Position         *services.Position           `json:"position"`
+		SubjectPositions map[string]services.Position `json:"subject_positions"`
+	}
+
+	tieBreak, err := h.rankingService.ResolveTieBreak(student.SchoolID, "")
+	if err != nil {
+		tieBreak = services.TieBreakShared
+	}
+
+	// A class is ranked once, however many summaries point at it
+	rankings := make(map[uuid.UUID]*services.ClassRanking)
+	withPositions := make([]SummaryWithPosition, len(summaries))
+	for i, summary := range summaries {
+		withPositions[i] = SummaryWithPosition{TermSummary: summary}
+
+		ranking, ranked := rankings[summary.ClassID]
+		if !ranked {
+			var class models.Class
+			if err := h.db.First(&class, "id = ?", summary.ClassID).Error; err == nil {
+				if ranking, err = h.rankingService.RankClass(&class, tieBreak); err != nil {
+					log.Printf("failed to rank class %s: %v", class.ID, err)
+				}
+			}
+			rankings[summary.ClassID] = ranking
+		}
+		if ranking != nil {
+			withPositions[i].Position, withPositions[i].SubjectPositions = ranking.StudentPositions(student.ID)
+		}
+	}
+
+	c.JSON(http.StatusOK, withPositions)
+}
+
 func (h *ResultHandler) CreateOrUpdate(c *gin.Context) {
-	userRole := c.GetString("user_role")
-	
 	var req struct {
 		StudentID   string                 `json:"student_id" binding:"required"`
 		SubjectID   string                 `json:"subject_id" binding:"required"`
@@ -102,13 +183,27 @@ func (h *ResultHandler) CreateOrUpdate(c *gin.Context) {
 		return
 	}
 	
-	// Get student's class from enrollment
-	var enrollment models.Enrollment
-	if err := h.db.Where("student_id = ?", studentID).Order("created_at DESC").First(&enrollment).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Student not enrolled in any class"})
+	// The result belongs to the class the student was in for its term
+	enrollment, err := h.enrollmentService.ForTerm(studentID, req.Term, req.Year)
+	if err != nil {
+		if errors.Is(err, services.ErrNotEnrolledForTerm) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Student is not enrolled for %s %d", req.Term, req.Year)})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	classID := enrollment.ClassID
+	class := *enrollment.Class
+	if rejectUnassigned(c, h.assignmentService, classID, subjectID) {
+		return
+	}
+	if rejectUndefinedTerm(c, h.calendarService, class.SchoolID, req.Term, req.Year) {
+		return
+	}
+	if rejectLockedTerm(c, h.lockService, class.SchoolID, req.Term, req.Year) {
+		return
+	}
 	
 	// Verify that the subject is a valid standard subject
 	var standardSubject models.StandardSubject
@@ -122,30 +217,24 @@ func (h *ResultHandler) CreateOrUpdate(c *gin.Context) {
 	err = h.db.Where("student_id = ? AND subject_id = ? AND term = ? AND year = ?",
 		studentID, subjectID, req.Term, req.Year).First(&result).Error
 	
-	// Teachers can only create new results, not edit existing ones
-	if userRole == "teacher" && err != gorm.ErrRecordNotFound {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Teachers cannot edit existing marks"})
+	// Results can only be edited while in draft; approvers return them to draft first
+	if err == nil && !services.ResultEditable(&result) {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Result is %s and can no longer be edited", result.Status)})
 		return
 	}
 	
-	// Calculate grade from total marks
-	total := 0.0
-	if req.RawMarks != nil {
-		if t, ok := req.RawMarks["total"].(float64); ok {
-			total = t
+	// Grade the submitted marks with the grader for the class level. Levels without
+	// a grader fall back to a manually entered final grade.
+	graded, gradeErr := h.computationService.GradeRawMarks(class.SchoolID, class.Level, &standardSubject, req.RawMarks)
+	if gradeErr != nil {
+		if !errors.Is(gradeErr, services.ErrNoGrader) || req.FinalGrade == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": gradeErr.Error()})
+			return
+		}
+		graded = grading.GradeResult{
+			FinalGrade:        req.FinalGrade,
+			ComputationReason: "Grade entered manually",
 		}
-	}
-	grade := ""
-	if total >= 80 {
-		grade = "A"
-	} else if total >= 65 {
-		grade = "B"
-	} else if total >= 50 {
-		grade = "C"
-	} else if total >= 35 {
-		grade = "D"
-	} else {
-		grade = "E"
 	}
 	
 	if err == gorm.ErrRecordNotFound {
@@ -156,10 +245,11 @@ func (h *ResultHandler) CreateOrUpdate(c *gin.Context) {
 			Term:       req.Term,
 			Year:       req.Year,
 			SchoolID:   uuid.MustParse(schoolID),
-			FinalGrade: grade,
 			RawMarks:   req.RawMarks,
+			Status:     services.ResultStatusDraft,
 		}
-		if err := h.db.Create(&result).Error; err != nil {
+		h.computationService.ApplyGrade(&result, graded)
+		if err := h.db.WithContext(c).Create(&result).Error; err != nil {
 			log.Printf("Error creating result: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
@@ -168,24 +258,223 @@ func (h *ResultHandler) CreateOrUpdate(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	} else {
-		// Only school admins can update existing results
-		result.FinalGrade = grade
+		result.ClassID = classID
 		result.RawMarks = req.RawMarks
-		if err := h.db.Save(&result).Error; err != nil {
+		h.computationService.ApplyGrade(&result, graded)
+		if err := h.db.WithContext(c).Save(&result).Error; err != nil {
 			log.Printf("Error saving result: %v", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 	}
+
+	if _, err := h.summaryService.ComputeForStudent(studentID, &class); err != nil {
+		log.Printf("Error computing term summary: %v", err)
+	}
 	
 	c.JSON(http.StatusOK, result)
 }
 
+// Compute derives results for a class and subject from the marks recorded against its assessments
+func (h *ResultHandler) Compute(c *gin.Context) {
+	var req struct {
+		ClassID   string `json:"class_id" binding:"required"`
+		SubjectID string `json:"subject_id" binding:"required"`
+		StudentID string `json:"student_id"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subjectID, err := uuid.Parse(req.SubjectID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subject ID"})
+		return
+	}
+
+	schoolID := c.GetString("tenant_school_id")
+
+	// Verify class belongs to the same school
+	var class models.Class
+	query := h.db.Where("id = ?", req.ClassID)
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+	if err := query.First(&class).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Class not found or access denied"})
+		return
+	}
+	if rejectUnassigned(c, h.assignmentService, class.ID, subjectID) {
+		return
+	}
+	if rejectUndefinedTerm(c, h.calendarService, class.SchoolID, class.Term, class.Year) {
+		return
+	}
+	if rejectLockedTerm(c, h.lockService, class.SchoolID, class.Term, class.Year) {
+		return
+	}
+
+	var studentIDs []uuid.UUID
+	enrollments := h.db.Model(&models.Enrollment{}).Where("class_id = ?", class.ID)
+	if req.StudentID != "" {
+		enrollments = enrollments.Where("student_id = ?", req.StudentID)
+	}
+	if err := enrollments.Pluck("student_id", &studentIDs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	computation := h.computationService.WithContext(c)
+	results := []models.SubjectResult{}
+	failures := []gin.H{}
+	for _, studentID := range studentIDs {
+		result, err := computation.ComputeSubjectResult(studentID, subjectID, &class)
+		if err != nil {
+			failures = append(failures, gin.H{"student_id": studentID, "error": err.Error()})
+			continue
+		}
+		results = append(results, *result)
+
+		if _, err := h.summaryService.ComputeForStudent(studentID, &class); err != nil {
+			log.Printf("Error computing term summary: %v", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results, "errors": failures})
+}
+
 func (h *ResultHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.db.Delete(&models.SubjectResult{}, "id = ?", id).Error; err != nil {
+	schoolID := c.GetString("tenant_school_id")
+
+	var result models.SubjectResult
+	query := h.db.Where("id = ?", id)
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+	if err := query.First(&result).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Result not found"})
+		return
+	}
+	if rejectLockedTerm(c, h.lockService, result.SchoolID, result.Term, result.Year) {
+		return
+	}
+	if result.Status == services.ResultStatusPublished {
+		c.JSON(http.StatusConflict, gin.H{"error": "Published results cannot be deleted"})
+		return
+	}
+
+	if err := h.db.WithContext(c).Delete(&result).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Result deleted"})
 }
+
+// Transition returns a handler that applies a workflow action (submit, approve,
+// return or publish) to the result in the :id param
+func (h *ResultHandler) Transition(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req struct {
+			Reason string `json:"reason"`
+		}
+		c.ShouldBindJSON(&req)
+
+		schoolID := c.GetString("tenant_school_id")
+		var result models.SubjectResult
+		query := h.db.Where("id = ?", c.Param("id"))
+		if schoolID != "" {
+			query = query.Where("school_id = ?", schoolID)
+		}
+		if err := query.First(&result).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Result not found"})
+			return
+		}
+		if rejectUnassigned(c, h.assignmentService, result.ClassID, result.SubjectID) {
+			return
+		}
+		if rejectLockedTerm(c, h.lockService, result.SchoolID, result.Term, result.Year) {
+			return
+		}
+
+		results, ok := h.transition(c, []models.SubjectResult{result}, action, req.Reason)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, results[0])
+	}
+}
+
+// TransitionClass applies a workflow action to every result for a class and
+// subject, e.g. to submit or publish a whole mark list at once
+func (h *ResultHandler) TransitionClass(c *gin.Context) {
+	var req struct {
+		ClassID   string `json:"class_id" binding:"required"`
+		SubjectID string `json:"subject_id" binding:"required"`
+		Action    string `json:"action" binding:"required"`
+		Reason    string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	subjectID, err := uuid.Parse(req.SubjectID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid subject ID"})
+		return
+	}
+
+	schoolID := c.GetString("tenant_school_id")
+	var class models.Class
+	query := h.db.Where("id = ?", req.ClassID)
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+	if err := query.First(&class).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Class not found or access denied"})
+		return
+	}
+	if rejectUnassigned(c, h.assignmentService, class.ID, subjectID) {
+		return
+	}
+	if rejectLockedTerm(c, h.lockService, class.SchoolID, class.Term, class.Year) {
+		return
+	}
+
+	var results []models.SubjectResult
+	if err := h.db.Where("class_id = ? AND subject_id = ? AND term = ? AND year = ?", class.ID, subjectID, class.Term, class.Year).
+		Find(&results).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(results) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No results for this class and subject"})
+		return
+	}
+
+	results, ok := h.transition(c, results, req.Action, req.Reason)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": len(results), "results": results})
+}
+
+// transition runs a workflow action and writes the error response if it fails
+func (h *ResultHandler) transition(c *gin.Context, results []models.SubjectResult, action, reason string) ([]models.SubjectResult, bool) {
+	updated, err := services.NewResultWorkflowService(h.db.WithContext(c)).Transition(results, action, reason, c.GetString("user_role"))
+	switch {
+	case err == nil:
+		return updated, true
+	case errors.Is(err, services.ErrNotApprover):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrReasonRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return nil, false
+}
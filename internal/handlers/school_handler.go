@@ -4,23 +4,20 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"github.com/school-system/backend/internal/models"
 	"github.com/school-system/backend/internal/services"
 	"gorm.io/gorm"
 )
 
 type SchoolHandler struct {
-	db           *gorm.DB
-	setupService *services.SchoolSetupService
-	auditService *services.AuditService
+	db     *gorm.DB
+	hasher *services.PasswordHasher
 }
 
-func NewSchoolHandler(db *gorm.DB) *SchoolHandler {
+func NewSchoolHandler(db *gorm.DB, hasher *services.PasswordHasher) *SchoolHandler {
 	return &SchoolHandler{
-		db:           db,
-		setupService: services.NewSchoolSetupService(db),
-		auditService: services.NewAuditService(db),
+		db:     db,
+		hasher: hasher,
 	}
 }
 
@@ -49,23 +46,23 @@ func (h *SchoolHandler) Create(c *gin.Context) {
 	}
 	req.School.Config["levels"] = req.Levels
 
-	if err := h.db.Create(&req.School).Error; err != nil {
+	if err := h.db.WithContext(c).Create(&req.School).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Log audit
-	if userID, exists := c.Get("user_id"); exists {
-		h.auditService.Log(userID.(uuid.UUID), "CREATE", "school", req.School.ID, nil, models.JSONB{"name": req.School.Name}, c.ClientIP())
-	}
-
 	// Setup school with classes, subjects, and grading rules
-	if err := h.setupService.SetupSchool(&req.School, req.Levels); err != nil {
+	credentials, err := services.NewSchoolSetupService(h.db.WithContext(c)).SetupSchool(&req.School, req.Levels, h.hasher)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to setup school: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, req.School)
+	// The admin's one-time password is only ever shown here
+	c.JSON(http.StatusCreated, struct {
+		models.School
+		AdminCredentials *services.InitialCredentials `json:"admin_credentials"`
+	}{req.School, credentials})
 }
 
 func (h *SchoolHandler) Get(c *gin.Context) {
@@ -103,7 +100,7 @@ func (h *SchoolHandler) Update(c *gin.Context) {
 	school.Motto = updateData.Motto
 	school.Config = updateData.Config
 
-	if err := h.db.Save(&school).Error; err != nil {
+	if err := h.db.WithContext(c).Save(&school).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -117,7 +114,7 @@ func (h *SchoolHandler) Update(c *gin.Context) {
 					levels = append(levels, level)
 				}
 			}
-			if err := h.setupService.SetupNewLevels(school.ID, levels); err != nil {
+			if err := services.NewSchoolSetupService(h.db.WithContext(c)).SetupNewLevels(school.ID, levels); err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to setup new levels: " + err.Error()})
 				return
 			}
@@ -163,15 +160,16 @@ func (h *SchoolHandler) SetupSchool(c *gin.Context) {
 			school.Config = make(models.JSONB)
 		}
 		school.Config["levels"] = levels
-		h.db.Save(&school)
+		h.db.WithContext(c).Save(&school)
 	}
 
-	if err := h.setupService.SetupSchool(&school, levels); err != nil {
+	credentials, err := services.NewSchoolSetupService(h.db.WithContext(c)).SetupSchool(&school, levels, h.hasher)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to setup school: " + err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "School setup completed successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "School setup completed successfully", "admin_credentials": credentials})
 }
 
 func (h *SchoolHandler) Delete(c *gin.Context) {
@@ -183,9 +181,9 @@ func (h *SchoolHandler) Delete(c *gin.Context) {
 	}
 
 	// Cascade delete all related data in proper order
-	err := h.db.Transaction(func(tx *gorm.DB) error {
+	err := h.db.WithContext(c).Transaction(func(tx *gorm.DB) error {
 		// Delete marks first (depends on assessments)
-		if err := tx.Exec("DELETE FROM marks WHERE assessment_id IN (SELECT id FROM assessments WHERE school_id = ?)", id).Error; err != nil {
+		if err := tx.Where("assessment_id IN (SELECT id FROM assessments WHERE school_id = ?)", id).Delete(&models.Mark{}).Error; err != nil {
 			return err
 		}
 		// Delete subject results
@@ -197,11 +195,11 @@ func (h *SchoolHandler) Delete(c *gin.Context) {
 			return err
 		}
 		// Delete report cards
-		if err := tx.Exec("DELETE FROM report_cards WHERE student_id IN (SELECT id FROM students WHERE school_id = ?)", id).Error; err != nil {
+		if err := tx.Where("student_id IN (SELECT id FROM students WHERE school_id = ?)", id).Delete(&models.ReportCard{}).Error; err != nil {
 			return err
 		}
 		// Delete enrollments
-		if err := tx.Exec("DELETE FROM enrollments WHERE student_id IN (SELECT id FROM students WHERE school_id = ?)", id).Error; err != nil {
+		if err := tx.Where("student_id IN (SELECT id FROM students WHERE school_id = ?)", id).Delete(&models.Enrollment{}).Error; err != nil {
 			return err
 		}
 		// Delete students
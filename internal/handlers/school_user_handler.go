@@ -1,36 +1,50 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
 	"github.com/school-system/backend/internal/services"
 	"gorm.io/gorm"
 )
 
 type SchoolUserHandler struct {
 	db                    *gorm.DB
+	hasher                *services.PasswordHasher
 	userAssignmentService *services.UserAssignmentService
 }
 
-func NewSchoolUserHandler(db *gorm.DB) *SchoolUserHandler {
+func NewSchoolUserHandler(db *gorm.DB, hasher *services.PasswordHasher) *SchoolUserHandler {
 	return &SchoolUserHandler{
 		db:                    db,
-		userAssignmentService: services.NewUserAssignmentService(db),
+		hasher:                hasher,
+		userAssignmentService: services.NewUserAssignmentService(db, hasher),
 	}
 }
 
-// GetSchoolUsers returns all users for a specific school
+// GetSchoolUsers returns the school's users, optionally filtered by role and
+// by active state (active=true|false)
 func (h *SchoolUserHandler) GetSchoolUsers(c *gin.Context) {
-	schoolIDStr := c.Param("schoolId")
-	schoolID, err := uuid.Parse(schoolIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid school ID"})
+	schoolID, ok := schoolScope(c)
+	if !ok {
 		return
 	}
 
-	users, err := h.userAssignmentService.GetSchoolUsers(schoolID)
+	var active *bool
+	if a := c.Query("active"); a != "" {
+		parsed, err := strconv.ParseBool(a)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid active filter - must be 'true' or 'false'"})
+			return
+		}
+		active = &parsed
+	}
+
+	users, err := h.userAssignmentService.GetSchoolUsers(schoolID, c.Query("role"), active)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -39,12 +53,10 @@ func (h *SchoolUserHandler) GetSchoolUsers(c *gin.Context) {
 	c.JSON(http.StatusOK, users)
 }
 
-// CreateTeacher creates a new teacher for a school
+// CreateTeacher creates a new teacher for the school
 func (h *SchoolUserHandler) CreateTeacher(c *gin.Context) {
-	schoolIDStr := c.Param("schoolId")
-	schoolID, err := uuid.Parse(schoolIDStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid school ID"})
+	schoolID, ok := schoolScope(c)
+	if !ok {
 		return
 	}
 
@@ -57,17 +69,26 @@ func (h *SchoolUserHandler) CreateTeacher(c *gin.Context) {
 		return
 	}
 
-	teacher, err := h.userAssignmentService.CreateTeacher(schoolID, req.FullName, req.Email)
+	teacher, password, err := services.NewUserAssignmentService(h.db.WithContext(c), h.hasher).CreateTeacher(schoolID, req.FullName, req.Email)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, teacher)
+	// The one-time password is only ever shown here
+	c.JSON(http.StatusCreated, struct {
+		*models.User
+		InitialPassword string `json:"initial_password"`
+	}{teacher, password})
 }
 
-// AssignTeacherToClass assigns a teacher to a specific class
+// AssignTeacherToClass makes a teacher the class teacher of one of the school's classes
 func (h *SchoolUserHandler) AssignTeacherToClass(c *gin.Context) {
+	schoolID, ok := schoolScope(c)
+	if !ok {
+		return
+	}
+
 	var req struct {
 		TeacherID string `json:"teacher_id" binding:"required"`
 		ClassID   string `json:"class_id" binding:"required"`
@@ -89,18 +110,31 @@ func (h *SchoolUserHandler) AssignTeacherToClass(c *gin.Context) {
 		return
 	}
 
-	if err := h.userAssignmentService.AssignTeacherToClass(teacherID, classID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// Verify class belongs to the same school
+	var count int64
+	h.db.Model(&models.Class{}).Where("id = ? AND school_id = ?", classID, schoolID).Count(&count)
+	if count == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Class not found or access denied"})
+		return
+	}
+
+	if err := services.NewUserAssignmentService(h.db.WithContext(c), h.hasher).AssignTeacherToClass(teacherID, classID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Teacher assigned to class successfully"})
 }
 
-// UpdateUserRole updates a user's role within the school
+// UpdateUserRole changes the role of one of the school's users. Only school
+// roles can be granted, never system_admin.
 func (h *SchoolUserHandler) UpdateUserRole(c *gin.Context) {
-	userIDStr := c.Param("userId")
-	userID, err := uuid.Parse(userIDStr)
+	schoolID, ok := schoolScope(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
 		return
@@ -114,10 +148,96 @@ func (h *SchoolUserHandler) UpdateUserRole(c *gin.Context) {
 		return
 	}
 
-	if err := h.userAssignmentService.UpdateUserRole(userID, req.Role); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := services.NewUserAssignmentService(h.db.WithContext(c), h.hasher).UpdateUserRole(schoolID, userID, req.Role); err != nil {
+		h.fail(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "User role updated successfully"})
-}
\ No newline at end of file
+}
+
+// Deactivate stops one of the school's users from signing in
+func (h *SchoolUserHandler) Deactivate(c *gin.Context) {
+	h.setActive(c, false)
+}
+
+// Reactivate lets a deactivated user sign in again
+func (h *SchoolUserHandler) Reactivate(c *gin.Context) {
+	h.setActive(c, true)
+}
+
+func (h *SchoolUserHandler) setActive(c *gin.Context, active bool) {
+	schoolID, ok := schoolScope(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	actorID, _ := c.Get("user_id")
+	if !active && actorID.(uuid.UUID) == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot deactivate your own account"})
+		return
+	}
+
+	if err := services.NewUserAssignmentService(h.db.WithContext(c), h.hasher).SetUserActive(schoolID, userID, active); err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully", "is_active": active})
+}
+
+// ResetPassword issues one of the school's users a new one-time password, for
+// users who lost theirs or whose stored default password was withdrawn
+func (h *SchoolUserHandler) ResetPassword(c *gin.Context) {
+	schoolID, ok := schoolScope(c)
+	if !ok {
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	password, err := services.NewUserAssignmentService(h.db.WithContext(c), h.hasher).ResetPassword(schoolID, userID)
+	if err != nil {
+		h.fail(c, err)
+		return
+	}
+
+	// The one-time password is only ever shown here
+	c.JSON(http.StatusOK, gin.H{"message": "Password reset successfully", "initial_password": password})
+}
+
+func (h *SchoolUserHandler) fail(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrUserNotInSchool):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// schoolScope resolves the school a request manages: the tenant school, or for
+// system admins the school_id query parameter
+func schoolScope(c *gin.Context) (uuid.UUID, bool) {
+	schoolID := c.GetString("tenant_school_id")
+	if schoolID == "" {
+		schoolID = c.Query("school_id")
+	}
+	parsed, err := uuid.Parse(schoolID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "School ID required"})
+		return uuid.Nil, false
+	}
+	return parsed, true
+}
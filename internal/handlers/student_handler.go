@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -8,15 +9,21 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/school-system/backend/internal/models"
+	"github.com/school-system/backend/internal/services"
+	"github.com/school-system/backend/internal/spreadsheet"
 	"gorm.io/gorm"
 )
 
 type StudentHandler struct {
-	db *gorm.DB
+	db              *gorm.DB
+	calendarService *services.AcademicCalendarService
 }
 
 func NewStudentHandler(db *gorm.DB) *StudentHandler {
-	return &StudentHandler{db: db}
+	return &StudentHandler{
+		db:              db,
+		calendarService: services.NewAcademicCalendarService(db),
+	}
 }
 
 func (h *StudentHandler) List(c *gin.Context) {
@@ -47,7 +54,8 @@ func (h *StudentHandler) List(c *gin.Context) {
 	if classID != "" {
 		query = query.Where("enrollments.class_id = ?", classID)
 	} else if classLevel != "" {
-		// Filter by class level, term, and year
+		// Filter by class level, term, and year, defaulting to the current term
+		term, year = defaultTerm(c, h.calendarService, term, year)
 		subQuery := h.db.Table("classes").Select("id").Where("level = ?", classLevel)
 		if term != "" {
 			subQuery = subQuery.Where("term = ?", term)
@@ -75,6 +83,7 @@ func (h *StudentHandler) Create(c *gin.Context) {
 		LastName   string `json:"last_name" binding:"required"`
 		Gender     string `json:"gender"`
 		ClassLevel string `json:"class_level" binding:"required"`
+		Stream     string `json:"stream"`
 		Term       string `json:"term" binding:"required"`
 		Year       int    `json:"year" binding:"required"`
 	}
@@ -107,31 +116,29 @@ func (h *StudentHandler) Create(c *gin.Context) {
 		school = *class.School
 	}
 
-	// Find the specific class for this school, level, term, and year
+	if rejectUndefinedTerm(c, h.calendarService, school.ID, req.Term, req.Year) {
+		return
+	}
+
+	// Find the specific class for this school, level, stream, term, and year
 	var class models.Class
-	if err := h.db.Where("school_id = ? AND level = ? AND term = ? AND year = ?", school.ID, req.ClassLevel, req.Term, req.Year).First(&class).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Class not found for the specified level, term, and year"})
+	if err := h.db.Where("school_id = ? AND level = ? AND stream = ? AND term = ? AND year = ?", school.ID, req.ClassLevel, req.Stream, req.Term, req.Year).First(&class).Error; err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Class not found for the specified level, stream, term, and year"})
 		return
 	}
 
-	// Count students in this class
+	// Count students across the level's streams so admission numbers stay unique
+	classIDs, err := services.LevelClassIDs(h.db, &class)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 	var count int64
 	h.db.Table("students").Joins("JOIN enrollments ON students.id = enrollments.student_id").
-		Where("enrollments.class_id = ?", class.ID).Count(&count)
+		Where("enrollments.class_id IN ?", classIDs).Count(&count)
 
 	// Generate admission number
-	schoolInitial := string(school.Name[0])
-	var schoolType string
-	switch school.Type {
-	case "Nursery":
-		schoolType = "NS"
-	case "Primary":
-		schoolType = "PS"
-	default:
-		schoolType = "SS"
-	}
-	sequence := int(count) + 1
-	admissionNo := fmt.Sprintf("%s%s/%s/%d/%03d", schoolInitial, schoolType, class.Level, req.Year, sequence)
+	admissionNo := services.AdmissionNo(&school, class.Level, req.Year, int(count)+1)
 
 	student := models.Student{
 		SchoolID:    school.ID,
@@ -141,7 +148,7 @@ func (h *StudentHandler) Create(c *gin.Context) {
 		Gender:      req.Gender,
 	}
 
-	if err := h.db.Create(&student).Error; err != nil {
+	if err := h.db.WithContext(c).Create(&student).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -154,11 +161,84 @@ func (h *StudentHandler) Create(c *gin.Context) {
 		Status:     "active",
 		EnrolledOn: time.Now(),
 	}
-	h.db.Create(&enrollment)
+	h.db.WithContext(c).Create(&enrollment)
 
 	c.JSON(http.StatusCreated, student)
 }
 
+// Import creates students and their enrollments from an uploaded CSV or XLSX
+// file. With dry_run=true it only returns the per-row validation report; a
+// commit creates nothing unless every row is valid.
+func (h *StudentHandler) Import(c *gin.Context) {
+	schoolID := c.GetString("tenant_school_id")
+	if schoolID == "" {
+		// System admins import into the school they name
+		schoolID = c.PostForm("school_id")
+	}
+
+	var school models.School
+	if schoolID == "" || h.db.First(&school, "id = ?", schoolID).Error != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "School not found"})
+		return
+	}
+
+	rows, ok := readUploadedSheet(c)
+	if !ok {
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true" || c.PostForm("dry_run") == "true"
+	report, err := services.NewStudentImportService(h.db.WithContext(c)).Import(&school, rows, dryRun)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch {
+	case report.Committed:
+		c.JSON(http.StatusCreated, report)
+	case !dryRun && report.Invalid > 0:
+		c.JSON(http.StatusUnprocessableEntity, report)
+	default:
+		c.JSON(http.StatusOK, report)
+	}
+}
+
+// readUploadedSheet reads the CSV or XLSX uploaded as "file". The request body
+// is capped so an oversized upload is refused before it is buffered.
+func readUploadedSheet(c *gin.Context) ([][]string, bool) {
+	// Leave room for the multipart envelope around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, spreadsheet.MaxFileSize+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("File is too large - the limit is %d MB", spreadsheet.MaxFileSize>>20)})
+			return nil, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return nil, false
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read file"})
+		return nil, false
+	}
+	defer file.Close()
+
+	rows, err := spreadsheet.Read(fileHeader.Filename, file)
+	if errors.Is(err, spreadsheet.ErrFileTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return rows, true
+}
+
 func (h *StudentHandler) Get(c *gin.Context) {
 	id := c.Param("id")
 	schoolID := c.GetString("tenant_school_id")
@@ -200,7 +280,7 @@ func (h *StudentHandler) Update(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Save(&student).Error; err != nil {
+	if err := h.db.WithContext(c).Save(&student).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -212,7 +292,7 @@ func (h *StudentHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 	schoolID := c.GetString("tenant_school_id")
 
-	query := h.db.Where("id = ?", id)
+	query := h.db.WithContext(c).Where("id = ?", id)
 
 	// Filter by school for non-system admins
 	if schoolID != "" {
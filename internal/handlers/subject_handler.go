@@ -117,7 +117,7 @@ func (h *SubjectHandler) CreateStandardSubject(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Create(&standardSubject).Error; err != nil {
+	if err := h.db.WithContext(c).Create(&standardSubject).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -138,7 +138,7 @@ func (h *SubjectHandler) UpdateStandardSubject(c *gin.Context) {
 		return
 	}
 
-	if err := h.db.Save(&standardSubject).Error; err != nil {
+	if err := h.db.WithContext(c).Save(&standardSubject).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -148,7 +148,7 @@ func (h *SubjectHandler) UpdateStandardSubject(c *gin.Context) {
 
 func (h *SubjectHandler) DeleteStandardSubject(c *gin.Context) {
 	id := c.Param("id")
-	if err := h.db.Delete(&models.StandardSubject{}, "id = ?", id).Error; err != nil {
+	if err := h.db.WithContext(c).Delete(&models.StandardSubject{}, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"github.com/school-system/backend/internal/services"
+	"gorm.io/gorm"
+)
+
+type TeacherAssignmentHandler struct {
+	db                *gorm.DB
+	assignmentService *services.TeacherAssignmentService
+}
+
+func NewTeacherAssignmentHandler(db *gorm.DB) *TeacherAssignmentHandler {
+	return &TeacherAssignmentHandler{
+		db:                db,
+		assignmentService: services.NewTeacherAssignmentService(db),
+	}
+}
+
+// List returns the school's subject teacher assignments, optionally for one
+// teacher or class
+func (h *TeacherAssignmentHandler) List(c *gin.Context) {
+	schoolID := c.GetString("tenant_school_id")
+
+	query := h.db.Preload("Teacher").Preload("Class").Preload("StandardSubject")
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+	if teacherID := c.Query("teacher_id"); teacherID != "" {
+		query = query.Where("teacher_id = ?", teacherID)
+	}
+	if classID := c.Query("class_id"); classID != "" {
+		query = query.Where("class_id = ?", classID)
+	}
+
+	var assignments []models.TeacherSubjectAssignment
+	if err := query.Order("year DESC, term DESC").Find(&assignments).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+// Create assigns a teacher to a subject in a class
+func (h *TeacherAssignmentHandler) Create(c *gin.Context) {
+	var req struct {
+		TeacherID uuid.UUID `json:"teacher_id" binding:"required"`
+		ClassID   uuid.UUID `json:"class_id" binding:"required"`
+		SubjectID uuid.UUID `json:"subject_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Verify class belongs to the same school
+	schoolID := c.GetString("tenant_school_id")
+	if schoolID != "" {
+		var count int64
+		h.db.Model(&models.Class{}).Where("id = ? AND school_id = ?", req.ClassID, schoolID).Count(&count)
+		if count == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Class not found or access denied"})
+			return
+		}
+	}
+
+	assignment, err := services.NewTeacherAssignmentService(h.db.WithContext(c)).Assign(req.TeacherID, req.ClassID, req.SubjectID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAssignment) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, assignment)
+}
+
+// Delete removes a subject teacher assignment
+func (h *TeacherAssignmentHandler) Delete(c *gin.Context) {
+	schoolID := c.GetString("tenant_school_id")
+
+	var assignment models.TeacherSubjectAssignment
+	query := h.db.Where("id = ?", c.Param("id"))
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+	if err := query.First(&assignment).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Assignment not found"})
+		return
+	}
+
+	// Removed outright so the teacher can be assigned again later
+	if err := h.db.WithContext(c).Unscoped().Delete(&assignment).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Assignment removed"})
+}
+
+// Mine returns the signed-in teacher's classes and subjects, optionally for
+// one term and year
+func (h *TeacherAssignmentHandler) Mine(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	year, _ := strconv.Atoi(c.Query("year"))
+
+	assignments, err := h.assignmentService.ForTeacher(userID.(uuid.UUID), c.Query("term"), year)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, assignments)
+}
+
+// rejectUnassigned writes a 403 response and returns true if the user may not
+// enter marks or results for the subject in the class
+func rejectUnassigned(c *gin.Context, assignments *services.TeacherAssignmentService, classID, subjectID uuid.UUID) bool {
+	userID, _ := c.Get("user_id")
+	allowed, err := assignments.CanWrite(userID.(uuid.UUID), c.GetString("user_role"), classID, subjectID)
+	switch {
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	case !allowed:
+		c.JSON(http.StatusForbidden, gin.H{"error": services.ErrNotAssigned.Error()})
+	default:
+		return false
+	}
+	return true
+}
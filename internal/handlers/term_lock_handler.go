@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"github.com/school-system/backend/internal/services"
+	"gorm.io/gorm"
+)
+
+type TermLockHandler struct {
+	db *gorm.DB
+}
+
+func NewTermLockHandler(db *gorm.DB) *TermLockHandler {
+	return &TermLockHandler{
+		db: db,
+	}
+}
+
+// List returns the school's locked terms
+func (h *TermLockHandler) List(c *gin.Context) {
+	schoolID := c.GetString("tenant_school_id")
+
+	var locks []models.TermLock
+	query := h.db.Order("year DESC, term DESC")
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+	if err := query.Find(&locks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, locks)
+}
+
+// Lock freezes marks and results for a term
+func (h *TermLockHandler) Lock(c *gin.Context) {
+	var req struct {
+		SchoolID string `json:"school_id"`
+		Term     string `json:"term" binding:"required"`
+		Year     int    `json:"year" binding:"required"`
+		Reason   string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schoolID := c.GetString("tenant_school_id")
+	if schoolID == "" {
+		// System admins lock the school they name
+		schoolID = req.SchoolID
+	}
+	parsedSchoolID, err := uuid.Parse(schoolID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "School ID required"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	lock, err := services.NewTermLockService(h.db.WithContext(c)).Lock(parsedSchoolID, req.Term, req.Year, req.Reason, userID.(uuid.UUID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, lock)
+}
+
+// Unlock reopens a locked term; a justification is mandatory and audited
+func (h *TermLockHandler) Unlock(c *gin.Context) {
+	var req struct {
+		Justification string `json:"justification" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": services.ErrJustificationRequired.Error()})
+		return
+	}
+
+	schoolID := c.GetString("tenant_school_id")
+	var lock models.TermLock
+	query := h.db.Where("id = ?", c.Param("id"))
+	if schoolID != "" {
+		query = query.Where("school_id = ?", schoolID)
+	}
+	if err := query.First(&lock).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Term lock not found"})
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+	if err := services.NewTermLockService(h.db.WithContext(c)).Unlock(&lock, req.Justification, userID.(uuid.UUID), c.ClientIP()); err != nil {
+		if errors.Is(err, services.ErrJustificationRequired) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Term unlocked"})
+}
+
+// rejectLockedTerm writes a 423 response and returns true if the term is locked
+func rejectLockedTerm(c *gin.Context, locks *services.TermLockService, schoolID uuid.UUID, term string, year int) bool {
+	err := locks.EnsureUnlocked(schoolID, term, year)
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, services.ErrTermLocked):
+		c.JSON(http.StatusLocked, gin.H{"error": err.Error(), "term": term, "year": year})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+	return true
+}
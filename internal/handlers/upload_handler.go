@@ -2,18 +2,22 @@ package handlers
 
 import (
 	"net/http"
+	"os"
+	"path/filepath"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/config"
 	"gorm.io/gorm"
 )
 
 type UploadHandler struct {
-	db *gorm.DB
+	db      *gorm.DB
+	logoDir string
 }
 
-func NewUploadHandler(db *gorm.DB) *UploadHandler {
-	return &UploadHandler{db: db}
+func NewUploadHandler(db *gorm.DB, storage config.StorageConfig) *UploadHandler {
+	return &UploadHandler{db: db, logoDir: storage.LogoDir()}
 }
 
 func (h *UploadHandler) UploadLogo(c *gin.Context) {
@@ -23,9 +27,13 @@ func (h *UploadHandler) UploadLogo(c *gin.Context) {
 		return
 	}
 
-	// Save to public/logos directory
+	// Save to the logo storage directory, served under /logos
+	if err := os.MkdirAll(h.logoDir, 0755); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return
+	}
 	filename := uuid.New().String() + ".png"
-	path := "public/logos/" + filename
+	path := filepath.Join(h.logoDir, filename)
 	if err := c.SaveUploadedFile(file, path); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
 		return
@@ -13,14 +13,12 @@ import (
 type UserHandler struct {
 	db          *gorm.DB
 	authService *services.AuthService
-	auditService *services.AuditService
 }
 
 func NewUserHandler(db *gorm.DB, authService *services.AuthService) *UserHandler {
 	return &UserHandler{
-		db: db, 
+		db:          db,
 		authService: authService,
-		auditService: services.NewAuditService(db),
 	}
 }
 
@@ -36,7 +34,7 @@ func (h *UserHandler) List(c *gin.Context) {
 func (h *UserHandler) Create(c *gin.Context) {
 	var req struct {
 		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=8"`
+		Password string `json:"password" binding:"required"`
 		FullName string `json:"full_name" binding:"required"`
 		Role     string `json:"role" binding:"required"`
 		SchoolID string `json:"school_id"`
@@ -53,11 +51,18 @@ func (h *UserHandler) Create(c *gin.Context) {
 		return
 	}
 
+	if err := services.ValidatePassword(req.Password); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A password chosen by an admin is only good for the first login
 	user := &models.User{
-		Email:    req.Email,
-		FullName: req.FullName,
-		Role:     req.Role,
-		IsActive: true,
+		Email:              req.Email,
+		FullName:           req.FullName,
+		Role:               req.Role,
+		IsActive:           true,
+		MustChangePassword: true,
 	}
 
 	if req.Role == "system_admin" {
@@ -71,16 +76,11 @@ func (h *UserHandler) Create(c *gin.Context) {
 		user.SchoolID = &schoolID
 	}
 
-	if err := h.authService.CreateUser(user, req.Password); err != nil {
+	if err := h.authService.WithContext(c).CreateUser(user, req.Password); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Log audit
-	if userID, exists := c.Get("user_id"); exists {
-		h.auditService.Log(userID.(uuid.UUID), "CREATE", "user", user.ID, nil, models.JSONB{"name": user.FullName, "role": user.Role}, c.ClientIP())
-	}
-
 	c.JSON(http.StatusCreated, user)
 }
 
@@ -127,33 +127,20 @@ func (h *UserHandler) Update(c *gin.Context) {
 		user.IsActive = *req.IsActive
 	}
 
-	if err := h.db.Save(&user).Error; err != nil {
+	if err := h.db.WithContext(c).Save(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Log audit
-	if userID, exists := c.Get("user_id"); exists {
-		h.auditService.Log(userID.(uuid.UUID), "UPDATE", "user", user.ID, nil, models.JSONB{"name": user.FullName}, c.ClientIP())
-	}
-
 	c.JSON(http.StatusOK, user)
 }
 
 func (h *UserHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
-	var user models.User
-	h.db.First(&user, "id = ?", id)
-	
-	if err := h.db.Delete(&models.User{}, "id = ?", id).Error; err != nil {
+	if err := h.db.WithContext(c).Delete(&models.User{}, "id = ?", id).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	// Log audit
-	if userID, exists := c.Get("user_id"); exists {
-		h.auditService.Log(userID.(uuid.UUID), "DELETE", "user", uuid.MustParse(id), models.JSONB{"name": user.FullName}, nil, c.ClientIP())
-	}
-
 	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
 }
@@ -0,0 +1,268 @@
+// Package jobs runs background work stored in the jobs table inside the API process
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	// StatusDead marks a job that failed on every allowed attempt
+	StatusDead = "dead"
+)
+
+const (
+	DefaultMaxAttempts = 3
+	DefaultBaseBackoff = 30 * time.Second
+	maxBackoff         = 30 * time.Minute
+	// staleAfter is how long a job may stay running before another instance
+	// assumes its worker died and requeues it
+	staleAfter = time.Hour
+)
+
+// HandlerFunc processes one job and returns its final result
+type HandlerFunc func(ctx context.Context, job *models.Job) (models.JSONB, error)
+
+// Runner polls the jobs table and dispatches pending jobs to registered handlers
+type Runner struct {
+	db          *gorm.DB
+	interval    time.Duration
+	maxAttempts int
+	baseBackoff time.Duration
+	mu          sync.RWMutex
+	handlers    map[string]HandlerFunc
+
+	stop   chan struct{}
+	done   chan struct{}
+	cancel context.CancelFunc
+}
+
+func NewRunner(db *gorm.DB, interval time.Duration) *Runner {
+	return &Runner{
+		db:          db,
+		interval:    interval,
+		maxAttempts: DefaultMaxAttempts,
+		baseBackoff: DefaultBaseBackoff,
+		handlers:    make(map[string]HandlerFunc),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+}
+
+// Register sets the handler for a job type
+func (r *Runner) Register(jobType string, handler HandlerFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[jobType] = handler
+}
+
+// Enqueue stores a pending job for the runner to pick up
+func Enqueue(db *gorm.DB, jobType string, payload models.JSONB) (*models.Job, error) {
+	now := time.Now()
+	job := models.Job{
+		Type:    jobType,
+		Payload: payload,
+		Status:  StatusPending,
+		RunAt:   &now,
+	}
+	if err := db.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("failed to enqueue %s job: %w", jobType, err)
+	}
+	return &job, nil
+}
+
+// UpdateResult stores intermediate progress on a running job
+func UpdateResult(db *gorm.DB, jobID uuid.UUID, result models.JSONB) error {
+	return db.Model(&models.Job{}).Where("id = ?", jobID).Update("result", result).Error
+}
+
+// Backoff is the delay before retrying a job that has failed attempts times,
+// doubling from base and capped at 30 minutes
+func Backoff(base time.Duration, attempts int) time.Duration {
+	if attempts < 1 {
+		return base
+	}
+	delay := base
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return delay
+}
+
+// Start polls for jobs in the background until Shutdown is called
+func (r *Runner) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	go func() {
+		defer close(r.done)
+		defer cancel()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+		for {
+			r.requeueStale()
+			for !r.stopping() && r.runNext(ctx) {
+			}
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Shutdown stops polling and waits for the running job to finish. If ctx expires
+// first the job's context is cancelled and the job is returned to the queue.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	close(r.stop)
+	select {
+	case <-r.done:
+		return nil
+	case <-ctx.Done():
+		if r.cancel != nil {
+			r.cancel()
+		}
+		<-r.done
+		return ctx.Err()
+	}
+}
+
+func (r *Runner) stopping() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// requeueStale returns jobs left running by a worker that died to the queue
+func (r *Runner) requeueStale() {
+	err := r.db.Model(&models.Job{}).
+		Where("status = ? AND started_at < ?", StatusRunning, time.Now().Add(-staleAfter)).
+		Updates(map[string]interface{}{"status": StatusPending, "run_at": time.Now()}).Error
+	if err != nil {
+		log.Printf("failed to requeue stale jobs: %v", err)
+	}
+}
+
+// claim locks the oldest due job of a registered type and marks it running.
+// SKIP LOCKED lets several API instances poll the same table without taking
+// the same job.
+func (r *Runner) claim(types []string) (*models.Job, error) {
+	var job models.Job
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND type IN ?", StatusPending, types).
+			Where("run_at IS NULL OR run_at <= ?", now).
+			Order("created_at").
+			First(&job).Error; err != nil {
+			return err
+		}
+
+		job.Status = StatusRunning
+		job.Attempts++
+		job.StartedAt = &now
+		return tx.Model(&models.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status":     job.Status,
+			"attempts":   job.Attempts,
+			"started_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// runNext claims and runs the oldest due job, reporting whether one was found
+func (r *Runner) runNext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	r.mu.RLock()
+	types := make([]string, 0, len(r.handlers))
+	for jobType := range r.handlers {
+		types = append(types, jobType)
+	}
+	r.mu.RUnlock()
+	if len(types) == 0 {
+		return false
+	}
+
+	job, err := r.claim(types)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("failed to claim job: %v", err)
+		}
+		return false
+	}
+
+	r.mu.RLock()
+	handler := r.handlers[job.Type]
+	r.mu.RUnlock()
+
+	result, err := handler(ctx, job)
+	r.finish(ctx, job, result, err)
+	return true
+}
+
+// finish records a job's outcome: completed, retried after a backoff, dead after
+// its last attempt, or requeued untouched if shutdown interrupted it
+func (r *Runner) finish(ctx context.Context, job *models.Job, result models.JSONB, err error) {
+	now := time.Now()
+	updates := map[string]interface{}{}
+	if result != nil {
+		updates["result"] = result
+	}
+
+	switch {
+	case err == nil:
+		updates["status"] = StatusCompleted
+		updates["finished_at"] = now
+	case ctx.Err() != nil:
+		log.Printf("job %s (%s) interrupted by shutdown, requeueing", job.ID, job.Type)
+		updates["status"] = StatusPending
+		updates["attempts"] = job.Attempts - 1
+		updates["run_at"] = now
+	case job.Attempts < r.maxAttempts:
+		retryAt := now.Add(Backoff(r.baseBackoff, job.Attempts))
+		log.Printf("job %s (%s) attempt %d failed, retrying at %s: %v", job.ID, job.Type, job.Attempts, retryAt.Format(time.RFC3339), err)
+		updates["status"] = StatusPending
+		updates["last_error"] = err.Error()
+		updates["run_at"] = retryAt
+	default:
+		log.Printf("job %s (%s) failed after %d attempts: %v", job.ID, job.Type, job.Attempts, err)
+		if result == nil {
+			result = models.JSONB{}
+		}
+		result["error"] = err.Error()
+		updates["result"] = result
+		updates["status"] = StatusDead
+		updates["last_error"] = err.Error()
+		updates["finished_at"] = now
+	}
+
+	if err := r.db.Model(&models.Job{}).Where("id = ?", job.ID).Updates(updates).Error; err != nil {
+		log.Printf("failed to record job %s status: %v", job.ID, err)
+	}
+}
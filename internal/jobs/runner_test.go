@@ -0,0 +1,224 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, 16 * time.Minute},
+		{7, 30 * time.Minute},
+		{20, 30 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(30*time.Second, tt.attempts); got != tt.expected {
+			t.Errorf("Backoff(30s, %d) = %s, expected %s", tt.attempts, got, tt.expected)
+		}
+	}
+}
+
+// newTestRunner returns a runner over a fresh SQLite jobs table. SQLite has no
+// row locks, so SKIP LOCKED is dropped; claiming is otherwise unchanged.
+func newTestRunner(t *testing.T) *Runner {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "jobs.db")), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(&models.Job{}); err != nil {
+		t.Fatalf("Failed to migrate jobs: %v", err)
+	}
+	return NewRunner(db, time.Second)
+}
+
+func loadJob(t *testing.T, r *Runner, job *models.Job) models.Job {
+	t.Helper()
+	var stored models.Job
+	if err := r.db.First(&stored, "id = ?", job.ID).Error; err != nil {
+		t.Fatalf("Failed to load job: %v", err)
+	}
+	return stored
+}
+
+func enqueue(t *testing.T, r *Runner, jobType string) *models.Job {
+	t.Helper()
+	job, err := Enqueue(r.db, jobType, models.JSONB{"n": 1})
+	if err != nil {
+		t.Fatalf("Failed to enqueue: %v", err)
+	}
+	return job
+}
+
+func TestClaim(t *testing.T) {
+	r := newTestRunner(t)
+
+	first := enqueue(t, r, "report")
+	second := enqueue(t, r, "report")
+	enqueue(t, r, "other")
+	later := enqueue(t, r, "report")
+	if err := r.db.Model(&models.Job{}).Where("id = ?", later.ID).Update("run_at", time.Now().Add(time.Hour)).Error; err != nil {
+		t.Fatalf("Failed to delay job: %v", err)
+	}
+
+	for _, expected := range []*models.Job{first, second} {
+		job, err := r.claim([]string{"report"})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if job.ID != expected.ID {
+			t.Errorf("Expected oldest due job %s, claimed %s", expected.ID, job.ID)
+		}
+
+		stored := loadJob(t, r, job)
+		if stored.Status != StatusRunning || stored.Attempts != 1 || stored.StartedAt == nil {
+			t.Errorf("Expected running with 1 attempt and a start time, got %s with %d (started %v)", stored.Status, stored.Attempts, stored.StartedAt)
+		}
+	}
+
+	// Running, unregistered and not-yet-due jobs are all skipped
+	if _, err := r.claim([]string{"report"}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected ErrRecordNotFound, got %v", err)
+	}
+}
+
+func TestRunNext_Completes(t *testing.T) {
+	r := newTestRunner(t)
+	r.Register("report", func(ctx context.Context, job *models.Job) (models.JSONB, error) {
+		return models.JSONB{"done": true}, nil
+	})
+	job := enqueue(t, r, "report")
+
+	if !r.runNext(context.Background()) {
+		t.Fatal("Expected a job to run")
+	}
+
+	stored := loadJob(t, r, job)
+	if stored.Status != StatusCompleted || stored.FinishedAt == nil {
+		t.Errorf("Expected completed with a finish time, got %s (finished %v)", stored.Status, stored.FinishedAt)
+	}
+	if stored.Result["done"] != true {
+		t.Errorf("Expected the handler's result to be stored, got %v", stored.Result)
+	}
+	if r.runNext(context.Background()) {
+		t.Error("Expected no further jobs")
+	}
+}
+
+func TestRunNext_RetriesThenDeadLetters(t *testing.T) {
+	r := newTestRunner(t)
+	r.maxAttempts = 2
+	r.baseBackoff = time.Minute
+
+	calls := 0
+	r.Register("report", func(ctx context.Context, job *models.Job) (models.JSONB, error) {
+		calls++
+		return models.JSONB{"progress": calls}, errors.New("render failed")
+	})
+	job := enqueue(t, r, "report")
+
+	before := time.Now()
+	if !r.runNext(context.Background()) {
+		t.Fatal("Expected a job to run")
+	}
+	stored := loadJob(t, r, job)
+	if stored.Status != StatusPending || stored.Attempts != 1 || stored.LastError != "render failed" {
+		t.Errorf("Expected pending retry after 1 attempt, got %s with %d (%q)", stored.Status, stored.Attempts, stored.LastError)
+	}
+	if stored.RunAt == nil || stored.RunAt.Before(before.Add(time.Minute)) {
+		t.Errorf("Expected the retry to wait out the backoff, run_at %v", stored.RunAt)
+	}
+
+	// Not due again until the backoff passes
+	if r.runNext(context.Background()) {
+		t.Fatal("Expected the retry to wait for its backoff")
+	}
+	if err := r.db.Model(&models.Job{}).Where("id = ?", job.ID).Update("run_at", time.Now().Add(-time.Second)).Error; err != nil {
+		t.Fatalf("Failed to expire backoff: %v", err)
+	}
+
+	if !r.runNext(context.Background()) {
+		t.Fatal("Expected the retry to run")
+	}
+	stored = loadJob(t, r, job)
+	if stored.Status != StatusDead || stored.Attempts != 2 || stored.FinishedAt == nil {
+		t.Errorf("Expected dead after 2 attempts, got %s with %d (finished %v)", stored.Status, stored.Attempts, stored.FinishedAt)
+	}
+	if stored.Result["error"] != "render failed" {
+		t.Errorf("Expected the final error in the result, got %v", stored.Result)
+	}
+
+	// Dead jobs are never claimed again
+	if r.runNext(context.Background()) || calls != 2 {
+		t.Errorf("Expected a dead job to stay dead, handler ran %d times", calls)
+	}
+}
+
+func TestRunNext_ShutdownRequeues(t *testing.T) {
+	r := newTestRunner(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	r.Register("report", func(ctx context.Context, job *models.Job) (models.JSONB, error) {
+		cancel()
+		return nil, ctx.Err()
+	})
+	job := enqueue(t, r, "report")
+
+	if !r.runNext(ctx) {
+		t.Fatal("Expected a job to run")
+	}
+
+	stored := loadJob(t, r, job)
+	if stored.Status != StatusPending || stored.Attempts != 0 || stored.LastError != "" {
+		t.Errorf("Expected an untouched pending job, got %s with %d (%q)", stored.Status, stored.Attempts, stored.LastError)
+	}
+}
+
+func TestRequeueStale(t *testing.T) {
+	r := newTestRunner(t)
+	stale := enqueue(t, r, "report")
+	active := enqueue(t, r, "report")
+
+	for job, started := range map[*models.Job]time.Time{
+		stale:  time.Now().Add(-2 * staleAfter),
+		active: time.Now().Add(-time.Minute),
+	} {
+		if err := r.db.Model(&models.Job{}).Where("id = ?", job.ID).
+			Updates(map[string]interface{}{"status": StatusRunning, "attempts": 1, "started_at": started}).Error; err != nil {
+			t.Fatalf("Failed to start job: %v", err)
+		}
+	}
+
+	r.requeueStale()
+
+	if stored := loadJob(t, r, stale); stored.Status != StatusPending || stored.Attempts != 1 {
+		t.Errorf("Expected the stale job back in the queue with its attempt kept, got %s with %d", stored.Status, stored.Attempts)
+	}
+	if stored := loadJob(t, r, active); stored.Status != StatusRunning {
+		t.Errorf("Expected the recently started job to keep running, got %s", stored.Status)
+	}
+}
@@ -8,40 +8,77 @@ import (
 	"github.com/school-system/backend/internal/services"
 )
 
+// AuthMiddleware authenticates requests with a full access token. Tokens that
+// only permit a password change are refused.
 func AuthMiddleware(authService *services.AuthService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+		claims, ok := authenticate(c, authService)
+		if !ok {
 			return
 		}
 
-		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+		if claims.Scope == services.TokenScopePasswordChange {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Password change required", "must_change_password": true})
 			c.Abort()
 			return
 		}
 
-		claims, err := authService.VerifyToken(parts[1])
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+		setClaims(c, claims)
+		c.Next()
+	}
+}
+
+// PasswordChangeMiddleware authenticates requests with any valid access token,
+// including the restricted one issued while a password change is pending
+func PasswordChangeMiddleware(authService *services.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := authenticate(c, authService)
+		if !ok {
 			return
 		}
 
-		c.Set("user_id", claims.UserID)
-		if claims.SchoolID != nil {
-			c.Set("school_id", claims.SchoolID.String())
-		}
-		c.Set("user_role", claims.Role)
-		c.Set("role", claims.Role)
-		c.Set("email", claims.Email)
+		setClaims(c, claims)
 		c.Next()
 	}
 }
 
+// authenticate verifies the bearer token, aborting with 401 if it is missing
+// or invalid
+func authenticate(c *gin.Context, authService *services.AuthService) (*services.Claims, bool) {
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
+		c.Abort()
+		return nil, false
+	}
+
+	parts := strings.SplitN(authHeader, " ", 2)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
+		c.Abort()
+		return nil, false
+	}
+
+	claims, err := authService.VerifyToken(parts[1])
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+		c.Abort()
+		return nil, false
+	}
+
+	return claims, true
+}
+
+func setClaims(c *gin.Context, claims *services.Claims) {
+	c.Set("user_id", claims.UserID)
+	if claims.SchoolID != nil {
+		c.Set("school_id", claims.SchoolID.String())
+	}
+	c.Set("user_role", claims.Role)
+	c.Set("role", claims.Role)
+	c.Set("email", claims.Email)
+}
+
 func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("role")
@@ -1,8 +1,12 @@
 package models
 
 import (
+	"crypto/sha256"
 	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -61,14 +65,15 @@ type School struct {
 // User represents system users (admin/teacher)
 type User struct {
 	BaseModel
-	SchoolID     *uuid.UUID `gorm:"type:char(36);index" json:"school_id"`
-	Email        string     `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
-	PasswordHash string     `gorm:"type:varchar(255);not null" json:"-"`
-	Role         string     `gorm:"type:varchar(20);not null" json:"role"`
-	FullName     string     `gorm:"type:varchar(255);not null" json:"full_name"`
-	IsActive     bool       `gorm:"default:true" json:"is_active"`
-	Meta         JSONB      `gorm:"type:json" json:"meta"`
-	School       *School    `gorm:"foreignKey:SchoolID" json:"school,omitempty"`
+	SchoolID           *uuid.UUID `gorm:"type:char(36);index" json:"school_id"`
+	Email              string     `gorm:"type:varchar(255);uniqueIndex;not null" json:"email"`
+	PasswordHash       string     `gorm:"type:varchar(255);not null" json:"-"`
+	Role               string     `gorm:"type:varchar(20);not null" json:"role"`
+	FullName           string     `gorm:"type:varchar(255);not null" json:"full_name"`
+	IsActive           bool       `gorm:"default:true" json:"is_active"`
+	MustChangePassword bool       `gorm:"not null;default:false" json:"must_change_password"` // until the one-time initial password is replaced
+	Meta               JSONB      `gorm:"type:json" json:"meta"`
+	School             *School    `gorm:"foreignKey:SchoolID" json:"school,omitempty"`
 }
 
 // Class represents a class/grade
@@ -77,6 +82,7 @@ type Class struct {
 	SchoolID  uuid.UUID  `gorm:"type:char(36);not null;index:idx_class_school_year_term" json:"school_id"`
 	Name      string     `gorm:"type:varchar(100);not null" json:"name"`
 	Level     string     `gorm:"type:varchar(50);not null" json:"level"`
+	Stream    string     `gorm:"type:varchar(50);not null;default:''" json:"stream"`
 	TeacherID *uuid.UUID `gorm:"type:char(36);index" json:"teacher_id"`
 	Year      int        `gorm:"not null;index:idx_class_school_year_term" json:"year"`
 	Term      string     `gorm:"type:varchar(10);not null;index:idx_class_school_year_term" json:"term"`
@@ -168,11 +174,32 @@ type SubjectResult struct {
 	FinalGrade          string          `gorm:"type:char(2)" json:"final_grade"`
 	ComputationReason   string          `gorm:"type:text" json:"computation_reason"`
 	RuleVersionHash     string          `gorm:"type:varchar(64)" json:"rule_version_hash"`
+	Status              string          `gorm:"type:varchar(20);default:'draft';index" json:"status"`
+	StatusReason        string          `gorm:"type:text" json:"status_reason,omitempty"`
 	Student             *Student        `gorm:"foreignKey:StudentID" json:"student,omitempty"`
 	StandardSubject     *StandardSubject `gorm:"foreignKey:SubjectID" json:"subject,omitempty"`
 	Class               *Class          `gorm:"foreignKey:ClassID" json:"class,omitempty"`
 }
 
+// TermSummary stores a student's overall results for a term
+type TermSummary struct {
+	BaseModel
+	StudentID         uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_unique_summary" json:"student_id"`
+	ClassID           uuid.UUID `gorm:"type:char(36);not null;index" json:"class_id"`
+	SchoolID          uuid.UUID `gorm:"type:char(36);not null;index" json:"school_id"`
+	Term              string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_unique_summary" json:"term"`
+	Year              int       `gorm:"not null;uniqueIndex:idx_unique_summary" json:"year"`
+	Level             string    `gorm:"type:varchar(50);not null" json:"level"`
+	SubjectCount      int       `json:"subject_count"`
+	AverageScore      float64   `gorm:"type:decimal(5,2)" json:"average_score"`
+	Aggregate         *int      `json:"aggregate,omitempty"`
+	Division          string    `gorm:"type:varchar(5)" json:"division,omitempty"`
+	Points            *int      `json:"points,omitempty"`
+	ComputationReason string    `gorm:"type:text" json:"computation_reason"`
+	Student           *Student  `gorm:"foreignKey:StudentID" json:"student,omitempty"`
+	Class             *Class    `gorm:"foreignKey:ClassID" json:"class,omitempty"`
+}
+
 // ReportCard represents generated report cards
 type ReportCard struct {
 	BaseModel
@@ -189,26 +216,88 @@ type ReportCard struct {
 	Class       *Class     `gorm:"foreignKey:ClassID" json:"class,omitempty"`
 }
 
-// AuditLog tracks all data changes
+// SkipAudit is a statement setting that keeps a write out of the audit
+// callbacks, for the few writes that log a more specific entry themselves:
+// db.Set(models.SkipAudit, true)
+const SkipAudit = "audit:skip"
+
+// AuditLog tracks all data changes. Entries form a hash chain: each carries a
+// hash of its content and of the entry before it, so editing or removing an
+// entry breaks every link after it. Entries are written pending, with sequence
+// 0 and no hash, alongside the change they record; a single chainer then gives
+// them their place in the chain (see AuditService.ChainPending).
 type AuditLog struct {
-	ID           uuid.UUID `gorm:"type:char(36);primaryKey" json:"id"`
-	ActorUserID  uuid.UUID `gorm:"type:char(36);index" json:"actor_user_id"`
-	Action       string    `gorm:"type:varchar(50);not null" json:"action"`
-	ResourceType string    `gorm:"type:varchar(50);not null;index" json:"resource_type"`
-	ResourceID   uuid.UUID `gorm:"type:char(36);index" json:"resource_id"`
-	Before       JSONB     `gorm:"type:json" json:"before"`
-	After        JSONB     `gorm:"type:json" json:"after"`
-	Timestamp    time.Time `gorm:"autoCreateTime;index" json:"timestamp"`
-	IP           string    `gorm:"type:varchar(45)" json:"ip"`
+	ID           uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
+	Sequence     int64      `gorm:"not null;default:0;uniqueIndex:idx_audit_sequence,where:sequence > 0;index:idx_audit_pending,where:sequence = 0" json:"sequence"`
+	ActorUserID  uuid.UUID  `gorm:"type:char(36);index" json:"actor_user_id"`
+	Action       string     `gorm:"type:varchar(50);not null" json:"action"`
+	ResourceType string     `gorm:"type:varchar(50);not null;index" json:"resource_type"`
+	ResourceID   uuid.UUID  `gorm:"type:char(36);index" json:"resource_id"`
+	SchoolID     *uuid.UUID `gorm:"type:char(36);index" json:"school_id,omitempty"`
+	Before       JSONB      `gorm:"type:json" json:"before"`
+	After        JSONB      `gorm:"type:json" json:"after"`
+	Timestamp    time.Time  `gorm:"autoCreateTime;index" json:"timestamp"`
+	IP           string     `gorm:"type:varchar(45)" json:"ip"`
+	PrevHash     string     `gorm:"type:char(64)" json:"prev_hash"`
+	Hash         string     `gorm:"type:char(64)" json:"hash"`
 }
 
 func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
 	if a.ID == uuid.Nil {
 		a.ID = uuid.New()
 	}
+	if a.Timestamp.IsZero() {
+		// Stored to the microsecond, so the hash survives the round trip
+		a.Timestamp = time.Now().UTC().Truncate(time.Microsecond)
+	}
 	return nil
 }
 
+// ChainHash is the SHA-256 of the entry's content and the previous entry's
+// hash. Each field is length-prefixed so values cannot run into each other.
+func (a *AuditLog) ChainHash() string {
+	schoolID := ""
+	if a.SchoolID != nil {
+		schoolID = a.SchoolID.String()
+	}
+	fields := []string{
+		strconv.FormatInt(a.Sequence, 10),
+		a.ID.String(),
+		a.ActorUserID.String(),
+		a.Action,
+		a.ResourceType,
+		a.ResourceID.String(),
+		schoolID,
+		canonicalJSON(a.Before),
+		canonicalJSON(a.After),
+		a.Timestamp.UTC().Format(time.RFC3339Nano),
+		a.IP,
+		a.PrevHash,
+	}
+
+	h := sha256.New()
+	for _, field := range fields {
+		fmt.Fprintf(h, "%d:%s;", len(field), field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// canonicalJSON encodes a snapshot the same way whether it was just built or
+// read back from the database: keys sorted, empty and missing alike
+func canonicalJSON(j JSONB) string {
+	if len(j) == 0 {
+		return "null"
+	}
+	// Round trip so Go values (UUIDs, times, ints) take their stored form
+	raw, _ := json.Marshal(j)
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return string(raw)
+	}
+	canonical, _ := json.Marshal(decoded)
+	return string(canonical)
+}
+
 // Job tracks background jobs
 type Job struct {
 	ID         uuid.UUID  `gorm:"type:char(36);primaryKey" json:"id"`
@@ -217,6 +306,9 @@ type Job struct {
 	Status     string     `gorm:"type:varchar(20);default:'pending';index" json:"status"`
 	Attempts   int        `gorm:"default:0" json:"attempts"`
 	Result     JSONB      `gorm:"type:json" json:"result"`
+	LastError  string     `gorm:"type:text" json:"last_error,omitempty"`
+	RunAt      *time.Time `gorm:"index" json:"run_at,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
 	CreatedAt  time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
 	FinishedAt *time.Time `json:"finished_at,omitempty"`
 }
@@ -228,6 +320,54 @@ func (j *Job) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// AcademicYear is a school's academic year and its terms
+type AcademicYear struct {
+	BaseModel
+	SchoolID  uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_academic_year_school" json:"school_id"`
+	Year      int       `gorm:"not null;uniqueIndex:idx_academic_year_school" json:"year"`
+	StartDate time.Time `gorm:"type:date;not null" json:"start_date"`
+	EndDate   time.Time `gorm:"type:date;not null" json:"end_date"`
+	Terms     []Term    `gorm:"foreignKey:AcademicYearID" json:"terms,omitempty"`
+}
+
+// Term is one term of an academic year; Name matches the Term field used on
+// classes, enrollments and results (e.g. "Term1")
+type Term struct {
+	BaseModel
+	AcademicYearID uuid.UUID `gorm:"type:char(36);not null;index" json:"academic_year_id"`
+	SchoolID       uuid.UUID `gorm:"type:char(36);not null;index:idx_term_school_year" json:"school_id"`
+	Year           int       `gorm:"not null;index:idx_term_school_year" json:"year"`
+	Name           string    `gorm:"type:varchar(10);not null" json:"name"`
+	StartDate      time.Time `gorm:"type:date;not null" json:"start_date"`
+	EndDate        time.Time `gorm:"type:date;not null" json:"end_date"`
+}
+
+// TermLock freezes marks and results for a school's term once report cards are out
+type TermLock struct {
+	BaseModel
+	SchoolID uuid.UUID `gorm:"type:char(36);not null;uniqueIndex:idx_term_lock,where:deleted_at IS NULL" json:"school_id"`
+	Year     int       `gorm:"not null;uniqueIndex:idx_term_lock" json:"year"`
+	Term     string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_term_lock" json:"term"`
+	LockedBy uuid.UUID `gorm:"type:char(36);not null" json:"locked_by"`
+	LockedAt time.Time `json:"locked_at"`
+	Reason   string    `gorm:"type:text" json:"reason,omitempty"`
+}
+
+// TeacherSubjectAssignment lets a teacher enter marks and results for one
+// subject in one class (stream) and term
+type TeacherSubjectAssignment struct {
+	BaseModel
+	SchoolID        uuid.UUID        `gorm:"type:char(36);not null;index" json:"school_id"`
+	TeacherID       uuid.UUID        `gorm:"type:char(36);not null;uniqueIndex:idx_teacher_class_subject" json:"teacher_id"`
+	ClassID         uuid.UUID        `gorm:"type:char(36);not null;uniqueIndex:idx_teacher_class_subject" json:"class_id"`
+	SubjectID       uuid.UUID        `gorm:"type:char(36);not null;uniqueIndex:idx_teacher_class_subject" json:"subject_id"`
+	Term            string           `gorm:"type:varchar(10);not null" json:"term"`
+	Year            int              `gorm:"not null" json:"year"`
+	Teacher         *User            `gorm:"foreignKey:TeacherID" json:"teacher,omitempty"`
+	Class           *Class           `gorm:"foreignKey:ClassID" json:"class,omitempty"`
+	StandardSubject *StandardSubject `gorm:"foreignKey:SubjectID" json:"subject,omitempty"`
+}
+
 // GradingRule stores grading configuration
 type GradingRule struct {
 	BaseModel
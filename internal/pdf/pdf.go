@@ -0,0 +1,250 @@
+// Package pdf writes simple single-font PDF documents (text, lines and images)
+// without external dependencies. Coordinates are in points from the top-left
+// corner of an A4 page.
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"io"
+	"strings"
+)
+
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// replacements maps common non-Latin-1 characters in stored text to ASCII
+var replacements = map[rune]string{
+	'→': "->",
+	'≥': ">=",
+	'≤': "<=",
+	'–': "-",
+	'—': "-",
+	'‘': "'",
+	'’': "'",
+	'“': "\"",
+	'”': "\"",
+}
+
+type page struct {
+	content bytes.Buffer
+	images  []int
+}
+
+type pdfImage struct {
+	width, height int
+	data          []byte
+}
+
+// Document is an in-memory PDF built page by page
+type Document struct {
+	pages  []*page
+	images []pdfImage
+}
+
+func New() *Document {
+	return &Document{}
+}
+
+// AddPage starts a new page; drawing calls go to the most recent page
+func (d *Document) AddPage() {
+	d.pages = append(d.pages, &page{})
+}
+
+func (d *Document) current() *page {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+	return d.pages[len(d.pages)-1]
+}
+
+// Text draws s with its baseline at (x, y) in Helvetica, or Helvetica-Bold when bold is set
+func (d *Document) Text(x, y, size float64, bold bool, s string) {
+	font := "F1"
+	if bold {
+		font = "F2"
+	}
+	fmt.Fprintf(&d.current().content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n",
+		font, size, x, PageHeight-y, escape(s))
+}
+
+// TextCentered draws s centred horizontally on the page
+func (d *Document) TextCentered(y, size float64, bold bool, s string) {
+	d.Text((PageWidth-TextWidth(s, size))/2, y, size, bold, s)
+}
+
+// Line draws a 0.5pt line from (x1, y1) to (x2, y2)
+func (d *Document) Line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&d.current().content, "0.5 w %.2f %.2f m %.2f %.2f l S\n",
+		x1, PageHeight-y1, x2, PageHeight-y2)
+}
+
+// Rect draws the outline of a rectangle whose top-left corner is (x, y)
+func (d *Document) Rect(x, y, w, h float64) {
+	fmt.Fprintf(&d.current().content, "0.5 w %.2f %.2f %.2f %.2f re S\n",
+		x, PageHeight-y-h, w, h)
+}
+
+// Image draws img scaled into the box whose top-left corner is (x, y).
+// Transparent pixels are composited onto white.
+func (d *Document) Image(img image.Image, x, y, w, h float64) error {
+	bounds := img.Bounds()
+	raw := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			r, g, b, a := img.At(px, py).RGBA()
+			white := 0xffff - a
+			raw = append(raw, byte((r+white)>>8), byte((g+white)>>8), byte((b+white)>>8))
+		}
+	}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(raw); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	d.images = append(d.images, pdfImage{width: bounds.Dx(), height: bounds.Dy(), data: compressed.Bytes()})
+	index := len(d.images) - 1
+
+	p := d.current()
+	p.images = append(p.images, index)
+	fmt.Fprintf(&p.content, "q %.2f 0 0 %.2f %.2f %.2f cm /Im%d Do Q\n", w, h, x, PageHeight-y-h, index)
+	return nil
+}
+
+// WriteTo serialises the document
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if len(d.pages) == 0 {
+		d.AddPage()
+	}
+
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string, stream []byte) int {
+		offsets = append(offsets, buf.Len())
+		id := len(offsets)
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\n", id, body)
+		if stream != nil {
+			buf.WriteString("stream\n")
+			buf.Write(stream)
+			buf.WriteString("\nendstream\n")
+		}
+		buf.WriteString("endobj\n")
+		return id
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// Object numbers: 1 catalog, 2 page tree, 3-4 fonts, then images, then page/content pairs
+	firstImage := 5
+	firstPage := firstImage + len(d.images)
+	pageRefs := make([]string, len(d.pages))
+	for i := range d.pages {
+		pageRefs[i] = fmt.Sprintf("%d 0 R", firstPage+i*2)
+	}
+
+	object("<< /Type /Catalog /Pages 2 0 R >>", nil)
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(pageRefs, " "), len(d.pages)), nil)
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>", nil)
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>", nil)
+
+	for _, img := range d.images {
+		object(fmt.Sprintf("<< /Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode /Length %d >>",
+			img.width, img.height, len(img.data)), img.data)
+	}
+
+	for i, p := range d.pages {
+		var xobjects strings.Builder
+		for _, index := range p.images {
+			fmt.Fprintf(&xobjects, " /Im%d %d 0 R", index, firstImage+index)
+		}
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> /XObject <<%s >> >> /Contents %d 0 R >>",
+			PageWidth, PageHeight, xobjects.String(), firstPage+i*2+1), nil)
+		object(fmt.Sprintf("<< /Length %d >>", p.content.Len()), p.content.Bytes())
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	n, err := w.Write(buf.Bytes())
+	return int64(n), err
+}
+
+// Bytes returns the serialised document
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	d.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// TextWidth approximates the width of s in Helvetica at the given size
+func TextWidth(s string, size float64) float64 {
+	width := 0.0
+	for _, r := range s {
+		switch {
+		case r == ' ' || strings.ContainsRune("ijlt.,:;'|!", r):
+			width += 0.28
+		case r >= 'A' && r <= 'Z', strings.ContainsRune("mw%@", r):
+			width += 0.72
+		default:
+			width += 0.55
+		}
+	}
+	return width * size
+}
+
+// Wrap splits s into lines that fit within width at the given size
+func Wrap(s string, size, width float64) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+		if line != "" && TextWidth(candidate, size) > width {
+			lines = append(lines, line)
+			candidate = word
+		}
+		line = candidate
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// escape encodes s as a WinAnsi PDF string literal body
+func escape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if replacement, ok := replacements[r]; ok {
+			b.WriteString(replacement)
+			continue
+		}
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 32 && r < 127:
+			b.WriteRune(r)
+		case r >= 160 && r <= 255:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestDocumentStructure(t *testing.T) {
+	doc := New()
+	doc.AddPage()
+	doc.Text(40, 50, 12, true, "Report (Term 1)")
+	doc.Line(40, 60, 200, 60)
+
+	logo := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	logo.Set(0, 0, color.RGBA{255, 0, 0, 255})
+	if err := doc.Image(logo, 40, 70, 20, 20); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	doc.AddPage()
+	doc.Text(40, 50, 10, false, "Page two")
+
+	out := doc.Bytes()
+	if !bytes.HasPrefix(out, []byte("%PDF-1.4")) || !bytes.HasSuffix(out, []byte("%%EOF\n")) {
+		t.Fatal("Missing PDF header or trailer")
+	}
+	if !bytes.Contains(out, []byte("/Count 2")) {
+		t.Error("Expected two pages in page tree")
+	}
+	if !bytes.Contains(out, []byte(`(Report \(Term 1\)) Tj`)) {
+		t.Error("Expected parentheses to be escaped in text")
+	}
+
+	// Every xref offset must point at the start of its object
+	xrefAt := bytes.LastIndex(out, []byte("xref\n"))
+	lines := strings.Split(string(out[xrefAt:]), "\n")
+	for i, line := range lines[3:] {
+		if !strings.HasSuffix(line, " n ") {
+			break
+		}
+		var offset int
+		fmt.Sscanf(line, "%d", &offset)
+		want := fmt.Sprintf("%d 0 obj", i+1)
+		if !bytes.HasPrefix(out[offset:], []byte(want)) {
+			t.Errorf("xref entry %d points at %q", i+1, out[offset:offset+10])
+		}
+	}
+}
+
+func TestEscapeAndWrap(t *testing.T) {
+	if got := escape("75 → Grade A"); got != "75 -> Grade A" {
+		t.Errorf("Expected arrow replaced, got %q", got)
+	}
+
+	lines := Wrap("one two three four five six seven", 10, 60)
+	if len(lines) < 2 {
+		t.Errorf("Expected text to wrap, got %v", lines)
+	}
+	for _, line := range lines {
+		if TextWidth(line, 10) > 60 && strings.Contains(line, " ") {
+			t.Errorf("Line %q exceeds width", line)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoCalendar    = errors.New("school has no academic calendar")
+	ErrUndefinedTerm = errors.New("term is not defined in the school's academic calendar")
+)
+
+// TermInput describes a term when defining an academic year
+type TermInput struct {
+	Name      string    `json:"name" binding:"required"`
+	StartDate time.Time `json:"start_date" binding:"required"`
+	EndDate   time.Time `json:"end_date" binding:"required"`
+}
+
+// DefaultTerms is the usual Ugandan three-term calendar for a year
+func DefaultTerms(year int) []TermInput {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	return []TermInput{
+		{Name: "Term1", StartDate: date(time.February, 1), EndDate: date(time.April, 30)},
+		{Name: "Term2", StartDate: date(time.May, 20), EndDate: date(time.August, 15)},
+		{Name: "Term3", StartDate: date(time.September, 5), EndDate: date(time.December, 5)},
+	}
+}
+
+type AcademicCalendarService struct {
+	db *gorm.DB
+}
+
+func NewAcademicCalendarService(db *gorm.DB) *AcademicCalendarService {
+	return &AcademicCalendarService{db: db}
+}
+
+// CreateYear defines an academic year and its terms. Terms must fall within the
+// year, have unique names and not overlap.
+func (s *AcademicCalendarService) CreateYear(schoolID uuid.UUID, year int, start, end time.Time, terms []TermInput) (*models.AcademicYear, error) {
+	if !end.After(start) {
+		return nil, errors.New("academic year must end after it starts")
+	}
+	if len(terms) == 0 {
+		return nil, errors.New("academic year needs at least one term")
+	}
+
+	sorted := append([]TermInput(nil), terms...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].StartDate.Before(sorted[j].StartDate) })
+	names := make(map[string]bool, len(sorted))
+	for i, term := range sorted {
+		if names[term.Name] {
+			return nil, fmt.Errorf("term %s is defined twice", term.Name)
+		}
+		names[term.Name] = true
+		if !term.EndDate.After(term.StartDate) {
+			return nil, fmt.Errorf("term %s must end after it starts", term.Name)
+		}
+		if term.StartDate.Before(start) || term.EndDate.After(end) {
+			return nil, fmt.Errorf("term %s falls outside the academic year", term.Name)
+		}
+		if i > 0 && !term.StartDate.After(sorted[i-1].EndDate) {
+			return nil, fmt.Errorf("term %s overlaps %s", term.Name, sorted[i-1].Name)
+		}
+	}
+
+	var count int64
+	s.db.Model(&models.AcademicYear{}).Where("school_id = ? AND year = ?", schoolID, year).Count(&count)
+	if count > 0 {
+		return nil, fmt.Errorf("academic year %d is already defined", year)
+	}
+
+	academicYear := models.AcademicYear{
+		SchoolID:  schoolID,
+		Year:      year,
+		StartDate: start,
+		EndDate:   end,
+	}
+	for _, term := range sorted {
+		academicYear.Terms = append(academicYear.Terms, models.Term{
+			SchoolID:  schoolID,
+			Year:      year,
+			Name:      term.Name,
+			StartDate: term.StartDate,
+			EndDate:   term.EndDate,
+		})
+	}
+	if err := s.db.Create(&academicYear).Error; err != nil {
+		return nil, fmt.Errorf("failed to create academic year: %w", err)
+	}
+	return &academicYear, nil
+}
+
+// EnsureYear returns the school's academic year, creating it with DefaultTerms if
+// it has not been defined
+func (s *AcademicCalendarService) EnsureYear(schoolID uuid.UUID, year int) (*models.AcademicYear, error) {
+	academicYear, err := s.FindYear(schoolID, year)
+	if err != nil || academicYear != nil {
+		return academicYear, err
+	}
+
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+	return s.CreateYear(schoolID, year, start, end, DefaultTerms(year))
+}
+
+// FindYear returns the school's academic year with its terms in order, or nil
+// if it has not been defined
+func (s *AcademicCalendarService) FindYear(schoolID uuid.UUID, year int) (*models.AcademicYear, error) {
+	var academicYear models.AcademicYear
+	err := s.db.Preload("Terms", func(db *gorm.DB) *gorm.DB { return db.Order("start_date") }).
+		Where("school_id = ? AND year = ?", schoolID, year).First(&academicYear).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &academicYear, nil
+}
+
+// DefaultYear is the unsaved academic year EnsureYear would create for a school
+// that has not defined one
+func DefaultYear(schoolID uuid.UUID, year int) *models.AcademicYear {
+	academicYear := &models.AcademicYear{SchoolID: schoolID, Year: year}
+	for _, term := range DefaultTerms(year) {
+		academicYear.Terms = append(academicYear.Terms, models.Term{
+			SchoolID:  schoolID,
+			Year:      year,
+			Name:      term.Name,
+			StartDate: term.StartDate,
+			EndDate:   term.EndDate,
+		})
+	}
+	return academicYear
+}
+
+// CurrentTerm resolves the term in session on the given date, or the most recent
+// one to have started during holidays
+func (s *AcademicCalendarService) CurrentTerm(schoolID uuid.UUID, at time.Time) (*models.Term, error) {
+	var term models.Term
+	err := s.db.Where("school_id = ? AND start_date <= ?", schoolID, at).
+		Order("start_date DESC").First(&term).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Before the first term starts, use the earliest defined term
+		err = s.db.Where("school_id = ?", schoolID).Order("start_date").First(&term).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoCalendar
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &term, nil
+}
+
+// ValidateTerm rejects a term and year the school has not defined. Schools that
+// have not set up a calendar yet are not checked.
+func (s *AcademicCalendarService) ValidateTerm(schoolID uuid.UUID, term string, year int) error {
+	var defined int64
+	if err := s.db.Model(&models.Term{}).Where("school_id = ?", schoolID).Count(&defined).Error; err != nil {
+		return err
+	}
+	if defined == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.Term{}).Where("school_id = ? AND name = ? AND year = ?", schoolID, term, year).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("%w: %s %d", ErrUndefinedTerm, term, year)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+)
+
+func utcDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCreateYear_RejectsBadTerms(t *testing.T) {
+	db := newTestDB(t, &models.AcademicYear{}, &models.Term{})
+	service := NewAcademicCalendarService(db)
+	schoolID := uuid.New()
+	start, end := utcDate(2026, time.January, 1), utcDate(2026, time.December, 31)
+
+	tests := []struct {
+		name     string
+		terms    []TermInput
+		expected string
+	}{
+		{"no terms", nil, "at least one term"},
+		{"overlapping terms", []TermInput{
+			{Name: "Term1", StartDate: utcDate(2026, time.February, 1), EndDate: utcDate(2026, time.May, 1)},
+			{Name: "Term2", StartDate: utcDate(2026, time.April, 20), EndDate: utcDate(2026, time.August, 1)},
+		}, "Term2 overlaps Term1"},
+		{"term starting the day another ends", []TermInput{
+			{Name: "Term2", StartDate: utcDate(2026, time.May, 1), EndDate: utcDate(2026, time.August, 1)},
+			{Name: "Term1", StartDate: utcDate(2026, time.February, 1), EndDate: utcDate(2026, time.May, 1)},
+		}, "Term2 overlaps Term1"},
+		{"duplicate name", []TermInput{
+			{Name: "Term1", StartDate: utcDate(2026, time.February, 1), EndDate: utcDate(2026, time.April, 1)},
+			{Name: "Term1", StartDate: utcDate(2026, time.May, 1), EndDate: utcDate(2026, time.August, 1)},
+		}, "defined twice"},
+		{"term ending before it starts", []TermInput{
+			{Name: "Term1", StartDate: utcDate(2026, time.April, 1), EndDate: utcDate(2026, time.February, 1)},
+		}, "must end after it starts"},
+		{"term outside the year", []TermInput{
+			{Name: "Term3", StartDate: utcDate(2026, time.September, 1), EndDate: utcDate(2027, time.January, 15)},
+		}, "outside the academic year"},
+	}
+	for _, tt := range tests {
+		_, err := service.CreateYear(schoolID, 2026, start, end, tt.terms)
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.expected, err)
+		}
+	}
+
+	if _, err := service.CreateYear(schoolID, 2026, end, start, DefaultTerms(2026)); err == nil {
+		t.Error("Expected a year ending before it starts to be rejected")
+	}
+
+	academicYear, err := service.CreateYear(schoolID, 2026, start, end, DefaultTerms(2026))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(academicYear.Terms) != 3 {
+		t.Errorf("Expected 3 terms, got %d", len(academicYear.Terms))
+	}
+	if _, err := service.CreateYear(schoolID, 2026, start, end, DefaultTerms(2026)); err == nil || !strings.Contains(err.Error(), "already defined") {
+		t.Errorf("Expected the year to be defined once, got %v", err)
+	}
+	// Another school keeps its own calendar
+	if _, err := service.CreateYear(uuid.New(), 2026, start, end, DefaultTerms(2026)); err != nil {
+		t.Errorf("Expected another school to define 2026, got %v", err)
+	}
+}
+
+func TestCurrentTerm(t *testing.T) {
+	db := newTestDB(t, &models.AcademicYear{}, &models.Term{})
+	service := NewAcademicCalendarService(db)
+	schoolID := uuid.New()
+
+	if _, err := service.CurrentTerm(schoolID, utcDate(2026, time.March, 1)); !errors.Is(err, ErrNoCalendar) {
+		t.Fatalf("Expected ErrNoCalendar, got %v", err)
+	}
+
+	for _, year := range []int{2025, 2026} {
+		if _, err := service.EnsureYear(schoolID, year); err != nil {
+			t.Fatalf("Failed to create %d: %v", year, err)
+		}
+	}
+	// Another school's calendar is never used
+	if _, err := service.CreateYear(uuid.New(), 2026, utcDate(2026, time.January, 1), utcDate(2026, time.December, 31), []TermInput{
+		{Name: "Term2", StartDate: utcDate(2026, time.June, 1), EndDate: utcDate(2026, time.June, 30)},
+	}); err != nil {
+		t.Fatalf("Failed to create the other school's year: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		at   time.Time
+		term string
+		year int
+	}{
+		{"in session", utcDate(2026, time.March, 10), "Term1", 2026},
+		{"first day", utcDate(2026, time.May, 20), "Term2", 2026},
+		{"holidays", utcDate(2026, time.August, 30), "Term2", 2026},
+		{"over the new year", utcDate(2026, time.January, 10), "Term3", 2025},
+		{"before the first term", utcDate(2024, time.June, 1), "Term1", 2025},
+	}
+	for _, tt := range tests {
+		term, err := service.CurrentTerm(schoolID, tt.at)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if term.Name != tt.term || term.Year != tt.year {
+			t.Errorf("%s: expected %s %d, got %s %d", tt.name, tt.term, tt.year, term.Name, term.Year)
+		}
+	}
+}
@@ -1,11 +1,29 @@
 package services
 
 import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/school-system/backend/internal/models"
 	"gorm.io/gorm"
 )
 
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 200
+	chainBatchSize       = 1000
+)
+
 type AuditService struct {
 	db *gorm.DB
 }
@@ -24,5 +42,359 @@ func (s *AuditService) Log(userID uuid.UUID, action, resourceType string, resour
 		After:        after,
 		IP:           ip,
 	}
+	// File the entry under the school it concerns, or else the actor's school
+	if resourceType == "school" {
+		log.SchoolID = &resourceID
+	} else {
+		var actor models.User
+		if err := s.db.Select("school_id").First(&actor, "id = ?", userID).Error; err == nil {
+			log.SchoolID = actor.SchoolID
+		}
+	}
 	return s.db.Create(log).Error
 }
+
+// AuditFilter narrows an audit log search. Zero values match everything.
+type AuditFilter struct {
+	SchoolID     *uuid.UUID
+	ActorID      *uuid.UUID
+	ResourceType string
+	ResourceID   *uuid.UUID
+	Action       string
+	From         *time.Time
+	To           *time.Time // exclusive
+	Cursor       string
+	Limit        int
+}
+
+// AuditEntry is an audit log row with the actor's name
+type AuditEntry struct {
+	models.AuditLog
+	UserName string `json:"user_name"`
+}
+
+// AuditPage is one page of search results, newest first. NextCursor fetches
+// the following page and is empty on the last one.
+type AuditPage struct {
+	Entries    []AuditEntry `json:"entries"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+// Search returns audit entries matching the filter, newest first, a page at a
+// time
+func (s *AuditService) Search(filter AuditFilter) (*AuditPage, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditPageSize
+	}
+	if limit > maxAuditPageSize {
+		limit = maxAuditPageSize
+	}
+
+	query := s.scoped(filter.SchoolID)
+	if filter.ActorID != nil {
+		query = query.Where("audit_logs.actor_user_id = ?", *filter.ActorID)
+	}
+	if filter.ResourceType != "" {
+		query = query.Where("audit_logs.resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != nil {
+		query = query.Where("audit_logs.resource_id = ?", *filter.ResourceID)
+	}
+	if filter.Action != "" {
+		query = query.Where("audit_logs.action = ?", strings.ToUpper(filter.Action))
+	}
+	if filter.From != nil {
+		query = query.Where("audit_logs.timestamp >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("audit_logs.timestamp < ?", *filter.To)
+	}
+	if filter.Cursor != "" {
+		at, id, err := decodeAuditCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("audit_logs.timestamp < ? OR (audit_logs.timestamp = ? AND audit_logs.id < ?)", at, at, id)
+	}
+
+	var entries []AuditEntry
+	if err := query.Order("audit_logs.timestamp DESC, audit_logs.id DESC").
+		Limit(limit + 1).
+		Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	page := &AuditPage{Entries: entries}
+	if len(entries) > limit {
+		page.Entries = entries[:limit]
+		last := page.Entries[limit-1]
+		page.NextCursor = encodeAuditCursor(last.Timestamp, last.ID)
+	}
+	if page.Entries == nil {
+		page.Entries = []AuditEntry{}
+	}
+	return page, nil
+}
+
+// FieldChange is one field's value before and after a change
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// HistoryEntry is one change in a resource's timeline
+type HistoryEntry struct {
+	ID          uuid.UUID     `json:"id"`
+	Action      string        `json:"action"`
+	ActorUserID uuid.UUID     `json:"actor_user_id"`
+	UserName    string        `json:"user_name"`
+	Timestamp   time.Time     `json:"timestamp"`
+	IP          string        `json:"ip"`
+	Changes     []FieldChange `json:"changes"`
+}
+
+// History returns every recorded change to a resource, oldest first, with the
+// fields each change touched
+func (s *AuditService) History(resourceType string, resourceID uuid.UUID, schoolID *uuid.UUID) ([]HistoryEntry, error) {
+	var entries []AuditEntry
+	if err := s.scoped(schoolID).
+		Where("audit_logs.resource_type = ? AND audit_logs.resource_id = ?", resourceType, resourceID).
+		Order("audit_logs.timestamp ASC, audit_logs.id ASC").
+		Scan(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	history := make([]HistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		history = append(history, HistoryEntry{
+			ID:          entry.ID,
+			Action:      entry.Action,
+			ActorUserID: entry.ActorUserID,
+			UserName:    entry.UserName,
+			Timestamp:   entry.Timestamp,
+			IP:          entry.IP,
+			Changes:     DiffFields(entry.Before, entry.After),
+		})
+	}
+	return history, nil
+}
+
+// DiffFields lists the fields whose values differ between two snapshots, in
+// field order. Bookkeeping timestamps are left out.
+func DiffFields(before, after models.JSONB) []FieldChange {
+	fields := make(map[string]bool, len(before)+len(after))
+	for field := range before {
+		fields[field] = true
+	}
+	for field := range after {
+		fields[field] = true
+	}
+
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		if field == "created_at" || field == "updated_at" {
+			continue
+		}
+		names = append(names, field)
+	}
+	sort.Strings(names)
+
+	changes := []FieldChange{}
+	for _, field := range names {
+		from, to := before[field], after[field]
+		if reflect.DeepEqual(from, to) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: field, From: from, To: to})
+	}
+	return changes
+}
+
+// scoped starts an audit log query joined to the actor, limited to one
+// school's trail when schoolID is set. Entries recorded before logs carried a
+// school fall back to the actor's school.
+func (s *AuditService) scoped(schoolID *uuid.UUID) *gorm.DB {
+	query := s.db.Table("audit_logs").
+		Select("audit_logs.*, users.full_name AS user_name").
+		Joins("LEFT JOIN users ON audit_logs.actor_user_id = users.id")
+	if schoolID != nil {
+		query = query.Where("audit_logs.school_id = ? OR (audit_logs.school_id IS NULL AND users.school_id = ?)", *schoolID, *schoolID)
+	}
+	return query
+}
+
+// auditChainLock is the advisory lock key that keeps chainers on several API
+// instances from linking entries at the same time
+const auditChainLock = 7316520431
+
+// pendingEntries matches audit entries not yet linked into the chain. An entry
+// whose sequence was cleared still has its hash, so it is not taken for one.
+const pendingEntries = "sequence = 0 AND (hash IS NULL OR hash = '')"
+
+// RunChainer links pending audit entries into the chain every interval until
+// ctx is done
+func (s *AuditService) RunChainer(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := s.ChainPending(); err != nil {
+			log.Printf("failed to chain audit entries: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ChainPending links committed entries that are not yet in the chain, oldest
+// first, and returns how many it linked. Each batch is its own short
+// transaction, so the chain is never held up by the writes being audited.
+func (s *AuditService) ChainPending() (int, error) {
+	chained := 0
+	for {
+		count, err := s.chainBatch()
+		chained += count
+		if err != nil || count < chainBatchSize {
+			return chained, err
+		}
+	}
+}
+
+func (s *AuditService) chainBatch() (int, error) {
+	count := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// SQLite, used in tests, only ever has one writer and no advisory locks
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLock).Error; err != nil {
+				return err
+			}
+		}
+
+		var last models.AuditLog
+		if err := tx.Select("sequence", "hash").Where("sequence > 0").
+			Order("sequence DESC").Limit(1).Find(&last).Error; err != nil {
+			return err
+		}
+		var batch []models.AuditLog
+		if err := tx.Where(pendingEntries).Order("timestamp, id").
+			Limit(chainBatchSize).Find(&batch).Error; err != nil {
+			return err
+		}
+
+		for i := range batch {
+			entry := &batch[i]
+			entry.Sequence = last.Sequence + 1
+			entry.PrevHash = last.Hash
+			entry.Hash = entry.ChainHash()
+			if err := tx.Model(entry).Updates(map[string]interface{}{
+				"sequence":  entry.Sequence,
+				"prev_hash": entry.PrevHash,
+				"hash":      entry.Hash,
+			}).Error; err != nil {
+				return err
+			}
+			last = *entry
+		}
+		count = len(batch)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ChainBreak is the first audit entry whose link in the hash chain fails
+type ChainBreak struct {
+	Sequence int64     `json:"sequence"`
+	EntryID  uuid.UUID `json:"entry_id"`
+	Reason   string    `json:"reason"`
+}
+
+// ChainReport is the outcome of walking the audit hash chain
+type ChainReport struct {
+	Valid        bool        `json:"valid"`
+	Checked      int64       `json:"checked"`
+	LastSequence int64       `json:"last_sequence"`
+	Unchained    int64       `json:"unchained"`
+	Pending      int64       `json:"pending"`
+	FirstBreak   *ChainBreak `json:"first_break,omitempty"`
+	CheckedAt    time.Time   `json:"checked_at"`
+}
+
+// VerifyChain walks the audit log in sequence order, recomputing each entry's
+// hash and checking it links to the one before, and stops at the first broken
+// link. Pending counts entries the chainer has yet to link; Unchained counts
+// entries taken out of the chain by clearing their sequence.
+func (s *AuditService) VerifyChain() (*ChainReport, error) {
+	report := &ChainReport{Valid: true, CheckedAt: time.Now()}
+	if err := s.db.Model(&models.AuditLog{}).Where(pendingEntries).Count(&report.Pending).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(&models.AuditLog{}).Where("sequence = 0 AND hash <> ''").Count(&report.Unchained).Error; err != nil {
+		return nil, err
+	}
+
+	prevHash := ""
+	for {
+		var batch []models.AuditLog
+		if err := s.db.Where("sequence > ?", report.LastSequence).
+			Order("sequence ASC").Limit(chainBatchSize).
+			Find(&batch).Error; err != nil {
+			return nil, err
+		}
+
+		for i := range batch {
+			entry := &batch[i]
+			reason := ""
+			switch {
+			case entry.Sequence != report.LastSequence+1:
+				reason = fmt.Sprintf("entries %d to %d are missing", report.LastSequence+1, entry.Sequence-1)
+			case entry.PrevHash != prevHash:
+				reason = "previous hash does not match the entry before it"
+			case entry.Hash != entry.ChainHash():
+				reason = "content does not match its hash"
+			}
+			if reason != "" {
+				report.Valid = false
+				report.FirstBreak = &ChainBreak{Sequence: entry.Sequence, EntryID: entry.ID, Reason: reason}
+				return report, nil
+			}
+			report.Checked++
+			report.LastSequence = entry.Sequence
+			prevHash = entry.Hash
+		}
+
+		if len(batch) < chainBatchSize {
+			return report, nil
+		}
+	}
+}
+
+func encodeAuditCursor(at time.Time, id uuid.UUID) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(at.UTC().Format(time.RFC3339Nano) + "|" + id.String()))
+}
+
+func decodeAuditCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	at, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return time.Time{}, uuid.Nil, ErrInvalidCursor
+	}
+	return at, id, nil
+}
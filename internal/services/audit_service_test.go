@@ -0,0 +1,313 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+)
+
+// newAuditChain writes count chained audit entries and returns the service
+func newAuditChain(t *testing.T, count int) *AuditService {
+	t.Helper()
+	service := NewAuditService(newTestDB(t, &models.User{}, &models.AuditLog{}))
+	schoolID := uuid.New()
+	for i := 0; i < count; i++ {
+		after := models.JSONB{"name": "Class", "n": float64(i)}
+		if err := service.Log(uuid.New(), "UPDATE", "school", schoolID, nil, after, "10.0.0.1"); err != nil {
+			t.Fatalf("Failed to log: %v", err)
+		}
+	}
+	if _, err := service.ChainPending(); err != nil {
+		t.Fatalf("Failed to chain: %v", err)
+	}
+	return service
+}
+
+func verifyChain(t *testing.T, service *AuditService) *ChainReport {
+	t.Helper()
+	report, err := service.VerifyChain()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	return report
+}
+
+func TestVerifyChain_Intact(t *testing.T) {
+	service := newAuditChain(t, 5)
+
+	report := verifyChain(t, service)
+	if !report.Valid || report.FirstBreak != nil {
+		t.Errorf("Expected an intact chain, got break %+v", report.FirstBreak)
+	}
+	if report.Checked != 5 || report.LastSequence != 5 || report.Unchained != 0 {
+		t.Errorf("Expected 5 entries checked up to sequence 5, got %d up to %d (%d unchained)", report.Checked, report.LastSequence, report.Unchained)
+	}
+}
+
+func TestVerifyChain_Empty(t *testing.T) {
+	report := verifyChain(t, newAuditChain(t, 0))
+	if !report.Valid || report.Checked != 0 {
+		t.Errorf("Expected an empty chain to be valid, got %+v", report)
+	}
+}
+
+func TestChainPending(t *testing.T) {
+	service := newAuditChain(t, 3)
+	schoolID := uuid.New()
+
+	// New entries wait outside the chain until the chainer links them
+	for i := 0; i < 2; i++ {
+		if err := service.Log(uuid.New(), "CREATE", "school", schoolID, nil, models.JSONB{"n": float64(i)}, "10.0.0.1"); err != nil {
+			t.Fatalf("Failed to log: %v", err)
+		}
+	}
+	var pending []models.AuditLog
+	service.db.Where("sequence = 0").Find(&pending)
+	if len(pending) != 2 || pending[0].Hash != "" {
+		t.Fatalf("Expected 2 pending entries without a hash, got %+v", pending)
+	}
+	if report := verifyChain(t, service); !report.Valid || report.Checked != 3 || report.Pending != 2 || report.Unchained != 0 {
+		t.Errorf("Expected 3 checked and 2 pending, got %+v", report)
+	}
+
+	chained, err := service.ChainPending()
+	if err != nil || chained != 2 {
+		t.Fatalf("Expected 2 entries chained, got %d (%v)", chained, err)
+	}
+	if again, _ := service.ChainPending(); again != 0 {
+		t.Errorf("Expected nothing left to chain, got %d", again)
+	}
+	report := verifyChain(t, service)
+	if !report.Valid || report.Checked != 5 || report.LastSequence != 5 || report.Pending != 0 {
+		t.Errorf("Expected 5 chained entries, got %+v", report)
+	}
+
+	// Two entries can never share a place in the chain
+	duplicate := models.AuditLog{Sequence: 5, Action: "CREATE", ResourceType: "school", Hash: "x"}
+	if err := service.db.Create(&duplicate).Error; err == nil {
+		t.Error("Expected a second entry at sequence 5 to be rejected")
+	}
+}
+
+func TestVerifyChain_TamperedEntry(t *testing.T) {
+	service := newAuditChain(t, 5)
+	if err := service.db.Model(&models.AuditLog{}).Where("sequence = ?", 3).
+		Update("action", "CREATE").Error; err != nil {
+		t.Fatalf("Failed to tamper: %v", err)
+	}
+
+	report := verifyChain(t, service)
+	if report.Valid || report.FirstBreak == nil {
+		t.Fatal("Expected the tampered entry to break the chain")
+	}
+	if report.FirstBreak.Sequence != 3 || !strings.Contains(report.FirstBreak.Reason, "content") {
+		t.Errorf("Expected a content break at 3, got %+v", report.FirstBreak)
+	}
+	if report.Checked != 2 {
+		t.Errorf("Expected the 2 entries before the break checked, got %d", report.Checked)
+	}
+}
+
+func TestVerifyChain_RehashedEntry(t *testing.T) {
+	service := newAuditChain(t, 5)
+
+	// Rewriting an entry along with its own hash still breaks the next link
+	var entry models.AuditLog
+	if err := service.db.First(&entry, "sequence = ?", 2).Error; err != nil {
+		t.Fatalf("Failed to load entry: %v", err)
+	}
+	entry.Action = "DELETE"
+	if err := service.db.Model(&entry).Updates(map[string]interface{}{"action": entry.Action, "hash": entry.ChainHash()}).Error; err != nil {
+		t.Fatalf("Failed to tamper: %v", err)
+	}
+
+	report := verifyChain(t, service)
+	if report.Valid || report.FirstBreak == nil || report.FirstBreak.Sequence != 3 {
+		t.Fatalf("Expected a break at 3, got %+v", report.FirstBreak)
+	}
+	if !strings.Contains(report.FirstBreak.Reason, "previous hash") {
+		t.Errorf("Expected a previous hash mismatch, got %q", report.FirstBreak.Reason)
+	}
+}
+
+func TestVerifyChain_MissingEntries(t *testing.T) {
+	service := newAuditChain(t, 5)
+	if err := service.db.Where("sequence IN ?", []int64{2, 3}).Delete(&models.AuditLog{}).Error; err != nil {
+		t.Fatalf("Failed to delete: %v", err)
+	}
+
+	report := verifyChain(t, service)
+	if report.Valid || report.FirstBreak == nil || report.FirstBreak.Sequence != 4 {
+		t.Fatalf("Expected a break at 4, got %+v", report.FirstBreak)
+	}
+	if report.FirstBreak.Reason != "entries 2 to 3 are missing" {
+		t.Errorf("Expected the gap reported, got %q", report.FirstBreak.Reason)
+	}
+}
+
+func TestVerifyChain_UnchainedEntry(t *testing.T) {
+	service := newAuditChain(t, 3)
+	if err := service.db.Model(&models.AuditLog{}).Where("sequence = ?", 3).
+		Update("sequence", 0).Error; err != nil {
+		t.Fatalf("Failed to unchain: %v", err)
+	}
+
+	// The chainer does not take it for a new entry
+	if chained, _ := service.ChainPending(); chained != 0 {
+		t.Errorf("Expected the unchained entry left alone, got %d chained", chained)
+	}
+
+	// Hiding the last entry leaves a valid chain, but it is counted
+	report := verifyChain(t, service)
+	if !report.Valid || report.Checked != 2 || report.Unchained != 1 {
+		t.Errorf("Expected 2 checked and 1 unchained, got %d checked and %d unchained", report.Checked, report.Unchained)
+	}
+}
+
+func TestAuditSearch(t *testing.T) {
+	db := newTestDB(t, &models.User{}, &models.AuditLog{})
+	service := NewAuditService(db)
+
+	schoolID, otherSchool := uuid.New(), uuid.New()
+	actor := models.User{SchoolID: &schoolID, Email: "head@school.ug", PasswordHash: "hash", Role: "school_admin", FullName: "Head Teacher", IsActive: true}
+	mustCreate(t, db, &actor)
+	studentID := uuid.New()
+
+	start := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	entries := []models.AuditLog{
+		{ActorUserID: actor.ID, Action: "CREATE", ResourceType: "student", ResourceID: studentID, SchoolID: &schoolID},
+		{ActorUserID: actor.ID, Action: "UPDATE", ResourceType: "student", ResourceID: studentID, SchoolID: &schoolID},
+		{ActorUserID: uuid.New(), Action: "UPDATE", ResourceType: "class", ResourceID: uuid.New(), SchoolID: &schoolID},
+		// Recorded before entries carried a school, so filed under the actor's
+		{ActorUserID: actor.ID, Action: "UPDATE", ResourceType: "subject_result", ResourceID: uuid.New()},
+		{ActorUserID: uuid.New(), Action: "UPDATE", ResourceType: "student", ResourceID: uuid.New(), SchoolID: &otherSchool},
+	}
+	for i := range entries {
+		entries[i].Timestamp = start.Add(time.Duration(i) * time.Hour)
+		mustCreate(t, db, &entries[i])
+	}
+
+	from, to := start.Add(time.Hour), start.Add(3*time.Hour)
+	tests := []struct {
+		name     string
+		filter   AuditFilter
+		expected []int
+	}{
+		{"school", AuditFilter{SchoolID: &schoolID}, []int{3, 2, 1, 0}},
+		{"other school", AuditFilter{SchoolID: &otherSchool}, []int{4}},
+		{"actor", AuditFilter{SchoolID: &schoolID, ActorID: &actor.ID}, []int{3, 1, 0}},
+		{"resource", AuditFilter{ResourceType: "student", ResourceID: &studentID}, []int{1, 0}},
+		{"action in any case", AuditFilter{SchoolID: &schoolID, Action: "update"}, []int{3, 2, 1}},
+		{"from inclusive, to exclusive", AuditFilter{From: &from, To: &to}, []int{2, 1}},
+	}
+	for _, tt := range tests {
+		page, err := service.Search(tt.filter)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		var got []int
+		for _, entry := range page.Entries {
+			for i := range entries {
+				if entry.ID == entries[i].ID {
+					got = append(got, i)
+				}
+			}
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected entries %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+
+	page, _ := service.Search(AuditFilter{ActorID: &actor.ID, Limit: 1})
+	if len(page.Entries) != 1 || page.Entries[0].UserName != "Head Teacher" {
+		t.Errorf("Expected the entry with its actor's name, got %+v", page.Entries)
+	}
+}
+
+func TestAuditSearch_Pages(t *testing.T) {
+	service := newAuditChain(t, 5)
+
+	seen := make(map[uuid.UUID]bool)
+	var last time.Time
+	filter := AuditFilter{Limit: 2}
+	for pages := 1; ; pages++ {
+		page, err := service.Search(filter)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, entry := range page.Entries {
+			if seen[entry.ID] {
+				t.Fatalf("Entry %s returned twice", entry.ID)
+			}
+			if !last.IsZero() && entry.Timestamp.After(last) {
+				t.Errorf("Expected newest first, got %s after %s", entry.Timestamp, last)
+			}
+			seen[entry.ID] = true
+			last = entry.Timestamp
+		}
+		if page.NextCursor == "" {
+			if pages != 3 {
+				t.Errorf("Expected 3 pages, got %d", pages)
+			}
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+	if len(seen) != 5 {
+		t.Errorf("Expected all 5 entries across the pages, got %d", len(seen))
+	}
+
+	if _, err := service.Search(AuditFilter{Cursor: "not-a-cursor"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestDiffFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   models.JSONB
+		after    models.JSONB
+		expected []FieldChange
+	}{
+		{
+			"changed field",
+			models.JSONB{"name": "Okello", "class": "S2"},
+			models.JSONB{"name": "Okello", "class": "S3"},
+			[]FieldChange{{Field: "class", From: "S2", To: "S3"}},
+		},
+		{
+			"created",
+			nil,
+			models.JSONB{"name": "Okello", "gender": "M"},
+			[]FieldChange{{Field: "gender", To: "M"}, {Field: "name", To: "Okello"}},
+		},
+		{
+			"deleted",
+			models.JSONB{"name": "Okello"},
+			nil,
+			[]FieldChange{{Field: "name", From: "Okello"}},
+		},
+		{
+			"nested values compared whole",
+			models.JSONB{"raw_marks": map[string]interface{}{"paper1": 60.0}},
+			models.JSONB{"raw_marks": map[string]interface{}{"paper1": 60.0}},
+			[]FieldChange{},
+		},
+		{
+			"timestamps left out",
+			models.JSONB{"name": "Okello", "updated_at": "2026-03-01"},
+			models.JSONB{"name": "Okello", "updated_at": "2026-03-02", "created_at": "2026-03-02"},
+			[]FieldChange{},
+		},
+	}
+	for _, tt := range tests {
+		if got := DiffFields(tt.before, tt.after); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.expected, got)
+		}
+	}
+}
@@ -1,10 +1,15 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
 	"errors"
+	"fmt"
+	"math/big"
+	"strings"
 	"time"
+	"unicode"
 
-	"github.com/alexedwards/argon2id"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/school-system/backend/internal/config"
@@ -17,12 +22,26 @@ var (
 	ErrUserNotActive      = errors.New("user not active")
 	ErrInvalidToken       = errors.New("invalid token")
 	ErrTokenRevoked       = errors.New("token revoked")
+
+	ErrPasswordChangeRequired = errors.New("password change required")
+	ErrWeakPassword           = errors.New("password does not meet the password policy")
+	ErrPasswordReused         = errors.New("new password must differ from the current one")
+)
+
+// TokenScopePasswordChange marks an access token that only permits changing
+// the password, issued at login while the user still has a one-time password
+const TokenScopePasswordChange = "password_change"
+
+const (
+	minPasswordLength         = 10
+	initialPasswordLength     = 14
+	passwordChangeTokenExpiry = 10 * time.Minute
 )
 
 type AuthService struct {
 	db     *gorm.DB
 	cfg    *config.Config
-	params *argon2id.Params
+	hasher *PasswordHasher
 }
 
 type TokenPair struct {
@@ -36,31 +55,25 @@ type Claims struct {
 	SchoolID *uuid.UUID `json:"school_id"`
 	Role     string     `json:"role"`
 	Email    string     `json:"email"`
+	Scope    string     `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewAuthService(db *gorm.DB, cfg *config.Config) *AuthService {
-	params := &argon2id.Params{
-		Memory:      cfg.Argon2.Memory,
-		Iterations:  cfg.Argon2.Iterations,
-		Parallelism: cfg.Argon2.Parallelism,
-		SaltLength:  cfg.Argon2.SaltLength,
-		KeyLength:   cfg.Argon2.KeyLength,
-	}
-
+func NewAuthService(db *gorm.DB, cfg *config.Config, hasher *PasswordHasher) *AuthService {
 	return &AuthService{
 		db:     db,
 		cfg:    cfg,
-		params: params,
+		hasher: hasher,
 	}
 }
 
 func (s *AuthService) HashPassword(password string) (string, error) {
-	return argon2id.CreateHash(password, s.params)
+	return s.hasher.Hash(password)
 }
 
 func (s *AuthService) VerifyPassword(hash, password string) (bool, error) {
-	return argon2id.ComparePasswordAndHash(password, hash)
+	match, _, err := s.hasher.Verify(hash, password)
+	return match, err
 }
 
 func (s *AuthService) Login(email, password string) (*TokenPair, *models.User, error) {
@@ -76,11 +89,32 @@ func (s *AuthService) Login(email, password string) (*TokenPair, *models.User, e
 		return nil, nil, ErrUserNotActive
 	}
 
-	match, err := s.VerifyPassword(user.PasswordHash, password)
+	match, rehash, err := s.hasher.Verify(user.PasswordHash, password)
 	if err != nil || !match {
 		return nil, nil, ErrInvalidCredentials
 	}
 
+	// Bring bcrypt and outdated hashes up to the configured argon2id
+	// parameters while the plain password is at hand. Failure is not fatal;
+	// the old hash still verifies.
+	if rehash {
+		if hash, err := s.hasher.Hash(password); err == nil {
+			if err := s.db.Model(&user).Update("password_hash", hash).Error; err == nil {
+				user.PasswordHash = hash
+			}
+		}
+	}
+
+	// Until the one-time password is replaced, the user only gets a token to
+	// change it with
+	if user.MustChangePassword {
+		tokens, err := s.generatePasswordChangeToken(&user)
+		if err != nil {
+			return nil, nil, err
+		}
+		return tokens, &user, nil
+	}
+
 	tokens, err := s.GenerateTokenPair(&user)
 	if err != nil {
 		return nil, nil, err
@@ -89,6 +123,141 @@ func (s *AuthService) Login(email, password string) (*TokenPair, *models.User, e
 	return tokens, &user, nil
 }
 
+// generatePasswordChangeToken issues a short-lived access token scoped to
+// changing the password, with no refresh token
+func (s *AuthService) generatePasswordChangeToken(user *models.User) (*TokenPair, error) {
+	claims := &Claims{
+		UserID:   user.ID,
+		SchoolID: user.SchoolID,
+		Role:     user.Role,
+		Email:    user.Email,
+		Scope:    TokenScopePasswordChange,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(passwordChangeTokenExpiry)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   user.ID.String(),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.cfg.JWT.Secret))
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken: token,
+		ExpiresIn:   int64(passwordChangeTokenExpiry.Seconds()),
+	}, nil
+}
+
+// ChangePassword replaces the user's password after checking the current one
+// and the password policy. It clears any pending forced change, signs the user
+// out of other sessions and returns a fresh, unrestricted token pair.
+func (s *AuthService) ChangePassword(userID uuid.UUID, current, next string) (*TokenPair, *models.User, error) {
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		return nil, nil, err
+	}
+	if !user.IsActive {
+		return nil, nil, ErrUserNotActive
+	}
+
+	match, err := s.VerifyPassword(user.PasswordHash, current)
+	if err != nil || !match {
+		return nil, nil, ErrInvalidCredentials
+	}
+	if current == next {
+		return nil, nil, ErrPasswordReused
+	}
+	if err := ValidatePassword(next); err != nil {
+		return nil, nil, err
+	}
+
+	hash, err := s.HashPassword(next)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password_hash":        hash,
+			"must_change_password": false,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked = ?", user.ID, false).
+			Update("revoked", true).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tokens, err := s.GenerateTokenPair(&user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, &user, nil
+}
+
+// ValidatePassword checks a password against the policy: at least
+// minPasswordLength characters with an upper-case letter, a lower-case letter
+// and a digit
+func ValidatePassword(password string) error {
+	if len([]rune(password)) < minPasswordLength {
+		return fmt.Errorf("%w: must be at least %d characters", ErrWeakPassword, minPasswordLength)
+	}
+
+	var upper, lower, digit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+
+	var missing []string
+	if !upper {
+		missing = append(missing, "an upper-case letter")
+	}
+	if !lower {
+		missing = append(missing, "a lower-case letter")
+	}
+	if !digit {
+		missing = append(missing, "a digit")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: must contain %s", ErrWeakPassword, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// passwordAlphabet leaves out characters that are easily misread (0/O, 1/l/I)
+const passwordAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789"
+
+// GenerateInitialPassword returns a random one-time password that satisfies
+// the password policy
+func GenerateInitialPassword() (string, error) {
+	max := big.NewInt(int64(len(passwordAlphabet)))
+	for {
+		password := make([]byte, initialPasswordLength)
+		for i := range password {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			password[i] = passwordAlphabet[n.Int64()]
+		}
+		if ValidatePassword(string(password)) == nil {
+			return string(password), nil
+		}
+	}
+}
+
 func (s *AuthService) GenerateTokenPair(user *models.User) (*TokenPair, error) {
 	// Access token
 	accessClaims := &Claims{
@@ -170,6 +339,10 @@ func (s *AuthService) RefreshTokens(refreshToken string) (*TokenPair, error) {
 		return nil, ErrUserNotActive
 	}
 
+	if user.MustChangePassword {
+		return nil, ErrPasswordChangeRequired
+	}
+
 	// Revoke old token
 	s.db.Model(&rt).Update("revoked", true)
 
@@ -202,6 +375,14 @@ func (s *AuthService) RevokeToken(refreshToken string) error {
 		Update("revoked", true).Error
 }
 
+// WithContext returns a copy of the service whose queries run with ctx, so
+// writes made on behalf of a request are audited against its user
+func (s *AuthService) WithContext(ctx context.Context) *AuthService {
+	scoped := *s
+	scoped.db = s.db.WithContext(ctx)
+	return &scoped
+}
+
 func (s *AuthService) CreateUser(user *models.User, password string) error {
 	hash, err := s.HashPassword(password)
 	if err != nil {
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		password string
+		valid    bool
+		missing  string
+	}{
+		{"Kampala2024x", true, ""},
+		{"Short1a", false, "at least 10 characters"},
+		{"kampala2024x", false, "an upper-case letter"},
+		{"KAMPALA2024X", false, "a lower-case letter"},
+		{"KampalaCity", false, "a digit"},
+		{"kampalacity", false, "an upper-case letter, a digit"},
+		// Length is counted in characters, not bytes
+		{"Ékampala1é", true, ""},
+	}
+
+	for _, tt := range tests {
+		err := ValidatePassword(tt.password)
+		if tt.valid {
+			if err != nil {
+				t.Errorf("%q: unexpected error: %v", tt.password, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrWeakPassword) {
+			t.Errorf("%q: expected ErrWeakPassword, got %v", tt.password, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.missing) {
+			t.Errorf("%q: expected the error to mention %q, got %q", tt.password, tt.missing, err)
+		}
+	}
+}
+
+func TestGenerateInitialPassword(t *testing.T) {
+	seen := make(map[string]bool)
+	for i := 0; i < 20; i++ {
+		password, err := GenerateInitialPassword()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(password) != initialPasswordLength {
+			t.Errorf("Expected %d characters, got %q", initialPasswordLength, password)
+		}
+		if err := ValidatePassword(password); err != nil {
+			t.Errorf("Generated password %q fails the policy: %v", password, err)
+		}
+		if strings.ContainsAny(password, "0O1lI") {
+			t.Errorf("Generated password %q contains an easily misread character", password)
+		}
+		if seen[password] {
+			t.Errorf("Generated password %q twice", password)
+		}
+		seen[password] = true
+	}
+}
@@ -0,0 +1,288 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// BroadsheetSubject is one subject column group on a broadsheet
+type BroadsheetSubject struct {
+	ID   uuid.UUID `json:"id"`
+	Code string    `json:"code"`
+	Name string    `json:"name"`
+}
+
+// BroadsheetCell is a student's result in one subject
+type BroadsheetCell struct {
+	Total float64 `json:"total"`
+	Grade string  `json:"grade"`
+}
+
+// BroadsheetRow is one student's line on a broadsheet
+type BroadsheetRow struct {
+	StudentID    uuid.UUID                 `json:"student_id"`
+	AdmissionNo  string                    `json:"admission_no"`
+	StudentName  string                    `json:"student_name"`
+	Stream       string                    `json:"stream,omitempty"`
+	Subjects     map[string]BroadsheetCell `json:"subjects"`
+	AverageScore *float64                  `json:"average_score,omitempty"`
+	Aggregate    *int                      `json:"aggregate,omitempty"`
+	Division     string                    `json:"division,omitempty"`
+	Points       *int                      `json:"points,omitempty"`
+	Position     *Position                 `json:"position,omitempty"`
+}
+
+// Broadsheet lists every student in a class with their subject results, overall
+// figures and position for a term
+type Broadsheet struct {
+	ClassID  uuid.UUID           `json:"class_id"`
+	Scope    string              `json:"scope"`
+	Level    string              `json:"level"`
+	Term     string              `json:"term"`
+	Year     int                 `json:"year"`
+	Subjects []BroadsheetSubject `json:"subjects"`
+	Rows     []BroadsheetRow     `json:"rows"`
+}
+
+type BroadsheetService struct {
+	db             *gorm.DB
+	rankingService *RankingService
+}
+
+func NewBroadsheetService(db *gorm.DB) *BroadsheetService {
+	return &BroadsheetService{
+		db:             db,
+		rankingService: NewRankingService(db),
+	}
+}
+
+// Build assembles the broadsheet for a class and term. With RankingScopeLevel
+// every stream of the class's level is included and ranked together. Subject
+// columns put compulsory subjects first, then order by code; rows are sorted
+// by position with unranked students last.
+func (s *BroadsheetService) Build(class *models.Class, term string, year int, scope string) (*Broadsheet, error) {
+	termClass := *class
+	termClass.Term = term
+	termClass.Year = year
+
+	tieBreak, err := s.rankingService.ResolveTieBreak(class.SchoolID, "")
+	if err != nil {
+		tieBreak = TieBreakShared
+	}
+
+	var ranking *ClassRanking
+	classIDs := []uuid.UUID{class.ID}
+	if scope == RankingScopeLevel {
+		if classIDs, err = LevelClassIDs(s.db, &termClass); err != nil {
+			return nil, err
+		}
+		ranking, err = s.rankingService.RankLevel(&termClass, tieBreak)
+	} else {
+		scope = RankingScopeClass
+		ranking, err = s.rankingService.RankClass(&termClass, tieBreak)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := &Broadsheet{
+		ClassID:  class.ID,
+		Scope:    scope,
+		Level:    class.Level,
+		Term:     term,
+		Year:     year,
+		Subjects: []BroadsheetSubject{},
+		Rows:     []BroadsheetRow{},
+	}
+
+	type studentRow struct {
+		StudentID   uuid.UUID
+		AdmissionNo string
+		FirstName   string
+		LastName    string
+		Stream      string
+	}
+	var students []studentRow
+	if err := s.db.Table("enrollments").
+		Select("DISTINCT students.id as student_id, students.admission_no, students.first_name, students.last_name, classes.stream").
+		Joins("JOIN students ON students.id = enrollments.student_id AND students.deleted_at IS NULL").
+		Joins("JOIN classes ON classes.id = enrollments.class_id").
+		Where("enrollments.class_id IN ? AND enrollments.deleted_at IS NULL", classIDs).
+		Scan(&students).Error; err != nil {
+		return nil, err
+	}
+	if len(students) == 0 {
+		return sheet, nil
+	}
+
+	var studentIDs []uuid.UUID
+	rows := make(map[uuid.UUID]*BroadsheetRow, len(students))
+	for _, st := range students {
+		if _, ok := rows[st.StudentID]; ok {
+			continue
+		}
+		studentIDs = append(studentIDs, st.StudentID)
+		rows[st.StudentID] = &BroadsheetRow{
+			StudentID:   st.StudentID,
+			AdmissionNo: st.AdmissionNo,
+			StudentName: st.FirstName + " " + st.LastName,
+			Stream:      st.Stream,
+			Subjects:    make(map[string]BroadsheetCell),
+		}
+	}
+
+	type resultRow struct {
+		StudentID    uuid.UUID
+		SubjectID    uuid.UUID
+		SubjectCode  string
+		SubjectName  string
+		FinalGrade   string
+		DerivedCodes models.JSONB
+	}
+	var results []resultRow
+	if err := s.db.Table("subject_results").
+		Select("subject_results.student_id, subject_results.subject_id, subject_results.final_grade, subject_results.derived_codes, standard_subjects.code as subject_code, standard_subjects.name as subject_name").
+		Joins("JOIN standard_subjects ON subject_results.subject_id = standard_subjects.id").
+		Where("subject_results.student_id IN ? AND subject_results.term = ? AND subject_results.year = ? AND subject_results.deleted_at IS NULL",
+			studentIDs, term, year).
+		Order("standard_subjects.is_compulsory DESC, standard_subjects.code").
+		Scan(&results).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[uuid.UUID]bool)
+	for _, result := range results {
+		if !seen[result.SubjectID] {
+			seen[result.SubjectID] = true
+			sheet.Subjects = append(sheet.Subjects, BroadsheetSubject{ID: result.SubjectID, Code: result.SubjectCode, Name: result.SubjectName})
+		}
+		total, _ := result.DerivedCodes["total"].(float64)
+		rows[result.StudentID].Subjects[result.SubjectCode] = BroadsheetCell{Total: total, Grade: result.FinalGrade}
+	}
+
+	var summaries []models.TermSummary
+	if err := s.db.Where("student_id IN ? AND term = ? AND year = ?", studentIDs, term, year).
+		Find(&summaries).Error; err != nil {
+		return nil, err
+	}
+	for _, summary := range summaries {
+		row := rows[summary.StudentID]
+		if summary.SubjectCount > 0 {
+			average := summary.AverageScore
+			row.AverageScore = &average
+		}
+		row.Aggregate = summary.Aggregate
+		row.Division = summary.Division
+		row.Points = summary.Points
+	}
+
+	for i := range ranking.Overall {
+		if row, ok := rows[ranking.Overall[i].StudentID]; ok {
+			row.Position = &ranking.Overall[i]
+		}
+	}
+
+	for _, studentID := range studentIDs {
+		sheet.Rows = append(sheet.Rows, *rows[studentID])
+	}
+	sort.SliceStable(sheet.Rows, func(i, j int) bool {
+		a, b := sheet.Rows[i].Position, sheet.Rows[j].Position
+		switch {
+		case a != nil && b != nil && a.Position != b.Position:
+			return a.Position < b.Position
+		case (a == nil) != (b == nil):
+			return a != nil
+		default:
+			return sheet.Rows[i].AdmissionNo < sheet.Rows[j].AdmissionNo
+		}
+	})
+
+	return sheet, nil
+}
+
+// Table flattens the broadsheet into spreadsheet rows with a header row. The
+// stream column appears on level-wide sheets; the aggregate, division and
+// points columns only when some student has them.
+func (b *Broadsheet) Table() [][]string {
+	var hasAggregate, hasDivision, hasPoints bool
+	for _, row := range b.Rows {
+		hasAggregate = hasAggregate || row.Aggregate != nil
+		hasDivision = hasDivision || row.Division != ""
+		hasPoints = hasPoints || row.Points != nil
+	}
+
+	withStream := b.Scope == RankingScopeLevel
+
+	header := []string{"Admission No", "Name"}
+	if withStream {
+		header = append(header, "Stream")
+	}
+	for _, subject := range b.Subjects {
+		header = append(header, subject.Code+" Total", subject.Code+" Grade")
+	}
+	header = append(header, "Average")
+	if hasAggregate {
+		header = append(header, "Aggregate")
+	}
+	if hasDivision {
+		header = append(header, "Division")
+	}
+	if hasPoints {
+		header = append(header, "Points")
+	}
+	header = append(header, "Position")
+
+	table := [][]string{header}
+	for _, row := range b.Rows {
+		line := []string{row.AdmissionNo, row.StudentName}
+		if withStream {
+			line = append(line, row.Stream)
+		}
+		for _, subject := range b.Subjects {
+			if cell, ok := row.Subjects[subject.Code]; ok {
+				line = append(line, formatScore(cell.Total), cell.Grade)
+			} else {
+				line = append(line, "", "")
+			}
+		}
+
+		line = append(line, "")
+		if row.AverageScore != nil {
+			line[len(line)-1] = formatScore(*row.AverageScore)
+		}
+		if hasAggregate {
+			line = append(line, optionalInt(row.Aggregate))
+		}
+		if hasDivision {
+			line = append(line, row.Division)
+		}
+		if hasPoints {
+			line = append(line, optionalInt(row.Points))
+		}
+		if row.Position != nil {
+			line = append(line, fmt.Sprintf("%d/%d", row.Position.Position, row.Position.OutOf))
+		} else {
+			line = append(line, "")
+		}
+		table = append(table, line)
+	}
+	return table
+}
+
+// formatScore prints a score to at most two decimal places
+func formatScore(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+func optionalInt(v *int) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.Itoa(*v)
+}
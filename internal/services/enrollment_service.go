@@ -0,0 +1,202 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotEnrolledForTerm = errors.New("student is not enrolled for the term")
+	ErrLastTerm           = errors.New("term is the last of its academic year; promote students to move them into the next year")
+)
+
+// CarryOverReport summarises copying enrollments into the next term
+type CarryOverReport struct {
+	FromTerm string `json:"from_term"`
+	ToTerm   string `json:"to_term"`
+	Year     int    `json:"year"`
+	DryRun   bool   `json:"dry_run"`
+	Copied   int    `json:"copied"`
+	Skipped  int    `json:"skipped"`
+	Left     int    `json:"left"`
+}
+
+type EnrollmentService struct {
+	db *gorm.DB
+}
+
+func NewEnrollmentService(db *gorm.DB) *EnrollmentService {
+	return &EnrollmentService{db: db}
+}
+
+// ForTerm returns the student's enrollment for a term and year, with its class
+func (s *EnrollmentService) ForTerm(studentID uuid.UUID, term string, year int) (*models.Enrollment, error) {
+	var enrollment models.Enrollment
+	err := s.db.Preload("Class").
+		Where("student_id = ? AND term = ? AND year = ?", studentID, term, year).
+		Order("created_at DESC").First(&enrollment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && enrollment.Class == nil) {
+		return nil, fmt.Errorf("%w: %s %d", ErrNotEnrolledForTerm, term, year)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+// CarryOver copies a school's active enrollments for a term into the class of
+// the same level in the following term of the year. Students who have left,
+// or are already enrolled in the next term, are skipped. Nothing is written on
+// a dry run.
+func (s *EnrollmentService) CarryOver(schoolID uuid.UUID, term string, year int, dryRun bool, actorID uuid.UUID, ip string) (*CarryOverReport, error) {
+	// Look the year up without creating it: CreateClassesForYear defines it
+	// when the carry-over is applied
+	academicYear, err := NewAcademicCalendarService(s.db).FindYear(schoolID, year)
+	if err != nil {
+		return nil, err
+	}
+	if academicYear == nil {
+		academicYear = DefaultYear(schoolID, year)
+	}
+	var next *models.Term
+	for i, t := range academicYear.Terms {
+		if t.Name != term {
+			continue
+		}
+		if i == len(academicYear.Terms)-1 {
+			return nil, fmt.Errorf("%w: %s %d", ErrLastTerm, term, year)
+		}
+		next = &academicYear.Terms[i+1]
+	}
+	if next == nil {
+		return nil, fmt.Errorf("%w: %s %d", ErrUndefinedTerm, term, year)
+	}
+
+	report := &CarryOverReport{FromTerm: term, ToTerm: next.Name, Year: year, DryRun: dryRun}
+
+	type enrollmentRow struct {
+		StudentID uuid.UUID
+		Level     string
+		Stream    string
+		Status    string
+		HasLeft   bool
+	}
+	var rows []enrollmentRow
+	if err := s.db.Table("enrollments").
+		Select("enrollments.student_id, classes.level, classes.stream, enrollments.status, enrollments.left_on IS NOT NULL as has_left").
+		Joins("JOIN students ON students.id = enrollments.student_id AND students.deleted_at IS NULL").
+		Joins("JOIN classes ON classes.id = enrollments.class_id").
+		Where("classes.school_id = ? AND enrollments.term = ? AND enrollments.year = ? AND enrollments.deleted_at IS NULL", schoolID, term, year).
+		Order("enrollments.created_at DESC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	var enrolled []uuid.UUID
+	if err := s.db.Model(&models.Enrollment{}).
+		Joins("JOIN classes ON classes.id = enrollments.class_id").
+		Where("classes.school_id = ? AND enrollments.term = ? AND enrollments.year = ?", schoolID, next.Name, year).
+		Pluck("enrollments.student_id", &enrolled).Error; err != nil {
+		return nil, err
+	}
+	alreadyEnrolled := uuidSet(enrolled)
+
+	var toCopy []enrollmentRow
+	levels := make(map[string]bool)
+	seen := make(map[uuid.UUID]bool)
+	for _, row := range rows {
+		if seen[row.StudentID] {
+			continue
+		}
+		seen[row.StudentID] = true
+		switch {
+		case row.Status != "active" || row.HasLeft:
+			report.Left++
+		case alreadyEnrolled[row.StudentID]:
+			report.Skipped++
+		default:
+			toCopy = append(toCopy, row)
+			levels[row.Level] = true
+		}
+	}
+	report.Copied = len(toCopy)
+	if dryRun || len(toCopy) == 0 {
+		return report, nil
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		nextLevels := make([]string, 0, len(levels))
+		for level := range levels {
+			nextLevels = append(nextLevels, level)
+		}
+		if err := NewSchoolSetupService(tx).CreateClassesForYear(schoolID, nextLevels, year); err != nil {
+			return fmt.Errorf("failed to create classes for %s %d: %w", next.Name, year, err)
+		}
+
+		var classes []models.Class
+		if err := tx.Where("school_id = ? AND term = ? AND year = ?", schoolID, next.Name, year).
+			Find(&classes).Error; err != nil {
+			return err
+		}
+		findClass := classFinder(classes)
+
+		for _, row := range toCopy {
+			classID, ok := findClass(row.Level, row.Stream)
+			if !ok {
+				return fmt.Errorf("no %s class for %s %d", row.Level, next.Name, year)
+			}
+			enrollment := models.Enrollment{
+				StudentID:  row.StudentID,
+				ClassID:    classID,
+				Year:       year,
+				Term:       next.Name,
+				Status:     "active",
+				EnrolledOn: next.StartDate,
+			}
+			if err := tx.Create(&enrollment).Error; err != nil {
+				return err
+			}
+		}
+
+		after := models.JSONB{
+			"from_term": term,
+			"to_term":   next.Name,
+			"year":      year,
+			"copied":    report.Copied,
+			"skipped":   report.Skipped,
+			"left":      report.Left,
+		}
+		return NewAuditService(tx).Log(actorID, "ENROLLMENT_CARRY_OVER", "school", schoolID, nil, after, ip)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// classFinder picks the class a student moves into: the class of the same
+// stream where the level has it, otherwise the level's unnamed class or its
+// first stream
+func classFinder(classes []models.Class) func(level, stream string) (uuid.UUID, bool) {
+	sort.Slice(classes, func(i, j int) bool { return classes[i].Stream < classes[j].Stream })
+	byStream := make(map[string]uuid.UUID, len(classes))
+	byLevel := make(map[string]uuid.UUID)
+	for _, class := range classes {
+		byStream[class.Level+"|"+class.Stream] = class.ID
+		if _, ok := byLevel[class.Level]; !ok {
+			byLevel[class.Level] = class.ID
+		}
+	}
+	return func(level, stream string) (uuid.UUID, bool) {
+		if id, ok := byStream[level+"|"+stream]; ok {
+			return id, true
+		}
+		id, ok := byLevel[level]
+		return id, ok
+	}
+}
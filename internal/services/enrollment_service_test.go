@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+)
+
+func TestClassFinder(t *testing.T) {
+	class := func(level, stream string) models.Class {
+		return models.Class{BaseModel: models.BaseModel{ID: uuid.New()}, Level: level, Stream: stream}
+	}
+	s2West := class("S2", "West")
+	s2East := class("S2", "East")
+	s3 := class("S3", "")
+	s3North := class("S3", "North")
+	s4South := class("S4", "South")
+	s4North := class("S4", "North")
+
+	findClass := classFinder([]models.Class{s2West, s2East, s3North, s3, s4South, s4North})
+
+	tests := []struct {
+		name     string
+		level    string
+		stream   string
+		expected uuid.UUID
+	}{
+		{"same stream", "S2", "West", s2West.ID},
+		{"same stream at a level with an unnamed class", "S3", "North", s3North.ID},
+		{"stream the level lacks falls back to the unnamed class", "S3", "West", s3.ID},
+		{"no stream falls back to the unnamed class", "S3", "", s3.ID},
+		{"stream the level lacks falls back to the first stream", "S4", "East", s4North.ID},
+		{"no stream falls back to the first stream", "S2", "", s2East.ID},
+	}
+
+	for _, tt := range tests {
+		id, ok := findClass(tt.level, tt.stream)
+		if !ok || id != tt.expected {
+			t.Errorf("%s: expected %s, got %s (found %v)", tt.name, tt.expected, id, ok)
+		}
+	}
+
+	if _, ok := findClass("S5", ""); ok {
+		t.Error("Expected no class for a level the school has none of")
+	}
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/grading"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type GradingRuleService struct {
+	db *gorm.DB
+}
+
+func NewGradingRuleService(db *gorm.DB) *GradingRuleService {
+	return &GradingRuleService{db: db}
+}
+
+// LoadRuleSet returns the school's validated grading rule for a level, or nil
+// when the school has no rule for the level and built-in grading applies
+func (s *GradingRuleService) LoadRuleSet(schoolID uuid.UUID, level string) (*grading.RuleSet, error) {
+	var rule models.GradingRule
+	err := s.db.Where("school_id = ? AND level = ?", schoolID, level).First(&rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := grading.ParseRuleSet(rule.RuleVersion, rule.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("grading rule for %s: %w", level, err)
+	}
+	return rules, nil
+}
+
+// GetSchoolRules returns all grading rules configured for a school
+func (s *GradingRuleService) GetSchoolRules(schoolID uuid.UUID) ([]models.GradingRule, error) {
+	var rules []models.GradingRule
+	if err := s.db.Where("school_id = ?", schoolID).Order("level").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// SaveRule validates and stores a school's grading rule for a level
+func (s *GradingRuleService) SaveRule(schoolID uuid.UUID, level, ruleVersion string, rules models.JSONB) (*models.GradingRule, error) {
+	if _, err := grading.ParseRuleSet(ruleVersion, rules); err != nil {
+		return nil, err
+	}
+
+	var rule models.GradingRule
+	err := s.db.Where("school_id = ? AND level = ?", schoolID, level).First(&rule).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	rule.SchoolID = schoolID
+	rule.Level = level
+	rule.RuleVersion = ruleVersion
+	rule.Rules = rules
+
+	if err := s.db.Save(&rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to save grading rule: %w", err)
+	}
+	return &rule, nil
+}
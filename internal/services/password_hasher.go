@@ -0,0 +1,71 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/school-system/backend/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// PasswordHasher is the one place passwords are hashed and checked. New hashes
+// are argon2id with the configured parameters; it still verifies the bcrypt
+// hashes some accounts were created with, and reports when a hash should be
+// replaced.
+type PasswordHasher struct {
+	params *argon2id.Params
+}
+
+func NewPasswordHasher(cfg config.Argon2Config) *PasswordHasher {
+	return &PasswordHasher{
+		params: &argon2id.Params{
+			Memory:      cfg.Memory,
+			Iterations:  cfg.Iterations,
+			Parallelism: cfg.Parallelism,
+			SaltLength:  cfg.SaltLength,
+			KeyLength:   cfg.KeyLength,
+		},
+	}
+}
+
+// Hash hashes a password with argon2id and the configured parameters
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	return argon2id.CreateHash(password, h.params)
+}
+
+// Verify checks a password against a hash. rehash is true when the password
+// matches but the hash is bcrypt or uses outdated argon2id parameters, so the
+// caller should store a fresh Hash of the password.
+func (h *PasswordHasher) Verify(hash, password string) (match, rehash bool, err error) {
+	if isBcryptHash(hash) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, err
+		}
+		return true, true, nil
+	}
+
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		return false, false, ErrUnknownHashFormat
+	}
+	match, params, err := argon2id.CheckHash(password, hash)
+	if err != nil || !match {
+		return false, false, err
+	}
+	return true, *params != *h.params, nil
+}
+
+func isBcryptHash(hash string) bool {
+	for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+		if strings.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+	return false
+}
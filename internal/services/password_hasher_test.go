@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/school-system/backend/internal/config"
+	"github.com/school-system/backend/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2 keeps hashing cheap in tests
+var testArgon2 = config.Argon2Config{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestPasswordHasher_Argon2(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2)
+
+	hash, err := hasher.Hash("Kampala2024x")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		t.Fatalf("Expected an argon2id hash, got %q", hash)
+	}
+
+	match, rehash, err := hasher.Verify(hash, "Kampala2024x")
+	if err != nil || !match || rehash {
+		t.Errorf("Expected a match without rehash, got match %v, rehash %v, error %v", match, rehash, err)
+	}
+
+	match, rehash, err = hasher.Verify(hash, "Kampala2024y")
+	if err != nil || match || rehash {
+		t.Errorf("Expected a mismatch, got match %v, rehash %v, error %v", match, rehash, err)
+	}
+}
+
+func TestPasswordHasher_OutdatedParams(t *testing.T) {
+	old := NewPasswordHasher(testArgon2)
+	hash, err := old.Hash("Kampala2024x")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	stronger := testArgon2
+	stronger.Iterations = 2
+	hasher := NewPasswordHasher(stronger)
+
+	match, rehash, err := hasher.Verify(hash, "Kampala2024x")
+	if err != nil || !match || !rehash {
+		t.Errorf("Expected a match asking for rehash, got match %v, rehash %v, error %v", match, rehash, err)
+	}
+
+	// A wrong password never asks for a rehash
+	if _, rehash, _ := hasher.Verify(hash, "wrong"); rehash {
+		t.Error("Expected no rehash for a wrong password")
+	}
+}
+
+func TestPasswordHasher_Bcrypt(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2)
+	hash, err := bcrypt.GenerateFromPassword([]byte("Admin@123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	match, rehash, err := hasher.Verify(string(hash), "Admin@123")
+	if err != nil || !match || !rehash {
+		t.Errorf("Expected a bcrypt match asking for rehash, got match %v, rehash %v, error %v", match, rehash, err)
+	}
+
+	match, rehash, err = hasher.Verify(string(hash), "Admin@124")
+	if err != nil || match || rehash {
+		t.Errorf("Expected a bcrypt mismatch, got match %v, rehash %v, error %v", match, rehash, err)
+	}
+}
+
+func TestPasswordHasher_UnknownFormat(t *testing.T) {
+	hasher := NewPasswordHasher(testArgon2)
+
+	for _, hash := range []string{"", "plain-text", "!0cc175b9c0f1b6a831c399e269772661"} {
+		match, _, err := hasher.Verify(hash, "plain-text")
+		if match || !errors.Is(err, ErrUnknownHashFormat) {
+			t.Errorf("%q: expected ErrUnknownHashFormat, got match %v, error %v", hash, match, err)
+		}
+	}
+}
+
+func TestLogin_RehashesBcrypt(t *testing.T) {
+	db := newTestDB(t, &models.School{}, &models.User{}, &models.RefreshToken{})
+	hasher := NewPasswordHasher(testArgon2)
+	cfg := &config.Config{JWT: config.JWTConfig{Secret: "secret", AccessExpiry: time.Minute, RefreshExpiry: time.Hour}}
+	auth := NewAuthService(db, cfg, hasher)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("Kampala2024x"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	user := models.User{Email: "teacher@school.ug", PasswordHash: string(hash), Role: "teacher", FullName: "A Teacher", IsActive: true}
+	mustCreate(t, db, &user)
+
+	if _, _, err := auth.Login("teacher@school.ug", "Kampala2024x"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	var stored models.User
+	if err := db.First(&stored, "id = ?", user.ID).Error; err != nil {
+		t.Fatalf("Failed to load user: %v", err)
+	}
+	if !strings.HasPrefix(stored.PasswordHash, "$argon2id$") {
+		t.Fatalf("Expected the bcrypt hash replaced with argon2id, got %q", stored.PasswordHash)
+	}
+	if match, rehash, err := hasher.Verify(stored.PasswordHash, "Kampala2024x"); err != nil || !match || rehash {
+		t.Errorf("Expected the new hash to verify as current, got match %v, rehash %v, error %v", match, rehash, err)
+	}
+
+	// Hashes withdrawn by the migration match no password
+	db.Model(&stored).Update("password_hash", "!0cc175b9c0f1b6a831c399e269772661")
+	if _, _, err := auth.Login("teacher@school.ug", "Kampala2024x"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+	}
+}
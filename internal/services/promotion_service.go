@@ -0,0 +1,375 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Promotion outcomes for a student at the end of a year
+const (
+	PromotionPromote  = "promote"
+	PromotionRepeat   = "repeat"
+	PromotionGraduate = "graduate"
+)
+
+// Enrollment statuses set when a year is rolled over
+const (
+	EnrollmentPromoted  = "promoted"
+	EnrollmentRepeated  = "repeated"
+	EnrollmentGraduated = "graduated"
+)
+
+// levelSequences lists each section's levels in order; the last level of a
+// section is terminal
+var levelSequences = [][]string{
+	{"Baby", "Middle", "Top"},
+	{"P1", "P2", "P3", "P4", "P5", "P6", "P7"},
+	{"S1", "S2", "S3", "S4", "S5", "S6"},
+}
+
+var (
+	ErrNothingToPromote = errors.New("no active enrollments to promote for the year")
+	ErrInvalidPassMark  = errors.New("pass mark must be between 0 and 100")
+)
+
+// PromotionRules decide who moves up. Students whose final-term average is
+// below the pass mark repeat, as do those on the repeat list; students on the
+// leaver list leave whatever their level. A nil pass mark falls back to the
+// school's "promotion_pass_mark" config, and no pass mark promotes everyone.
+type PromotionRules struct {
+	PassMark         *float64    `json:"pass_mark"`
+	RepeatStudentIDs []uuid.UUID `json:"repeat_student_ids"`
+	LeaverStudentIDs []uuid.UUID `json:"leaver_student_ids"`
+}
+
+// PromotionDecision is what happens to one student at rollover
+type PromotionDecision struct {
+	StudentID    uuid.UUID `json:"student_id"`
+	AdmissionNo  string    `json:"admission_no"`
+	StudentName  string    `json:"student_name"`
+	EnrollmentID uuid.UUID `json:"enrollment_id"`
+	FromLevel    string    `json:"from_level"`
+	Stream       string    `json:"stream,omitempty"`
+	ToLevel      string    `json:"to_level,omitempty"`
+	Action       string    `json:"action"`
+	Reason       string    `json:"reason"`
+	AverageScore *float64  `json:"average_score,omitempty"`
+}
+
+// PromotionPlan is the outcome of rolling a school's year over
+type PromotionPlan struct {
+	SchoolID  uuid.UUID           `json:"school_id"`
+	Year      int                 `json:"year"`
+	NextYear  int                 `json:"next_year"`
+	NextTerm  string              `json:"next_term"`
+	PassMark  *float64            `json:"pass_mark,omitempty"`
+	Promoted  int                 `json:"promoted"`
+	Repeating int                 `json:"repeating"`
+	Graduated int                 `json:"graduated"`
+	Decisions []PromotionDecision `json:"decisions"`
+}
+
+type PromotionService struct {
+	db *gorm.DB
+}
+
+func NewPromotionService(db *gorm.DB) *PromotionService {
+	return &PromotionService{db: db}
+}
+
+// Preview works out each student's promotion for a year without changing anything
+func (s *PromotionService) Preview(schoolID uuid.UUID, year int, rules PromotionRules) (*PromotionPlan, error) {
+	return s.plan(s.db, schoolID, year, rules)
+}
+
+// Commit applies the promotion plan in one transaction. Next year's classes are
+// created as needed; each student's final enrollment is closed and, unless they
+// graduate, they are enrolled in the first term of the next year, keeping their
+// stream where the next level has it.
+func (s *PromotionService) Commit(schoolID uuid.UUID, year int, rules PromotionRules, actorID uuid.UUID, ip string) (*PromotionPlan, error) {
+	var plan *PromotionPlan
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		plan, err = s.plan(tx, schoolID, year, rules)
+		if err != nil {
+			return err
+		}
+
+		levels := make(map[string]bool)
+		for _, decision := range plan.Decisions {
+			if decision.ToLevel != "" {
+				levels[decision.ToLevel] = true
+			}
+		}
+		nextLevels := make([]string, 0, len(levels))
+		for level := range levels {
+			nextLevels = append(nextLevels, level)
+		}
+		sort.Strings(nextLevels)
+		if err := NewSchoolSetupService(tx).CreateClassesForYear(schoolID, nextLevels, plan.NextYear); err != nil {
+			return fmt.Errorf("failed to create classes for %d: %w", plan.NextYear, err)
+		}
+
+		// Terms are in place now, so the next year's first term is defined
+		var firstTerm models.Term
+		if err := tx.Where("school_id = ? AND year = ?", schoolID, plan.NextYear).
+			Order("start_date").First(&firstTerm).Error; err != nil {
+			return fmt.Errorf("failed to find the first term of %d: %w", plan.NextYear, err)
+		}
+		plan.NextTerm = firstTerm.Name
+
+		var classes []models.Class
+		if err := tx.Where("school_id = ? AND year = ? AND term = ?", schoolID, plan.NextYear, firstTerm.Name).
+			Find(&classes).Error; err != nil {
+			return err
+		}
+		findClass := classFinder(classes)
+
+		today := time.Now()
+		for _, decision := range plan.Decisions {
+			status := EnrollmentPromoted
+			switch decision.Action {
+			case PromotionRepeat:
+				status = EnrollmentRepeated
+			case PromotionGraduate:
+				status = EnrollmentGraduated
+			}
+			// Close every enrollment the student still has open in the year
+			if err := tx.Model(&models.Enrollment{}).
+				Where("student_id = ? AND year = ? AND status = ?", decision.StudentID, plan.Year, "active").
+				Updates(map[string]interface{}{"status": status, "left_on": today}).Error; err != nil {
+				return err
+			}
+
+			if decision.Action == PromotionGraduate {
+				continue
+			}
+			classID, ok := findClass(decision.ToLevel, decision.Stream)
+			if !ok {
+				return fmt.Errorf("no %s class for %s %d", decision.ToLevel, firstTerm.Name, plan.NextYear)
+			}
+			enrollment := models.Enrollment{
+				StudentID:  decision.StudentID,
+				ClassID:    classID,
+				Year:       plan.NextYear,
+				Term:       firstTerm.Name,
+				Status:     "active",
+				EnrolledOn: firstTerm.StartDate,
+			}
+			if err := tx.Create(&enrollment).Error; err != nil {
+				return err
+			}
+		}
+
+		after := models.JSONB{
+			"year":      plan.Year,
+			"next_year": plan.NextYear,
+			"promoted":  plan.Promoted,
+			"repeating": plan.Repeating,
+			"graduated": plan.Graduated,
+		}
+		if plan.PassMark != nil {
+			after["pass_mark"] = *plan.PassMark
+		}
+		return NewAuditService(tx).Log(actorID, "PROMOTION_COMMIT", "school", schoolID, nil, after, ip)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// plan decides each student's promotion from their latest active enrollment
+// in the year. Students already enrolled in the next year are left out.
+func (s *PromotionService) plan(db *gorm.DB, schoolID uuid.UUID, year int, rules PromotionRules) (*PromotionPlan, error) {
+	passMark, err := s.resolvePassMark(db, schoolID, rules.PassMark)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &PromotionPlan{
+		SchoolID:  schoolID,
+		Year:      year,
+		NextYear:  year + 1,
+		NextTerm:  DefaultTerms(year + 1)[0].Name,
+		PassMark:  passMark,
+		Decisions: []PromotionDecision{},
+	}
+	var firstTerm models.Term
+	if err := db.Where("school_id = ? AND year = ?", schoolID, plan.NextYear).
+		Order("start_date").First(&firstTerm).Error; err == nil {
+		plan.NextTerm = firstTerm.Name
+	}
+
+	type enrollmentRow struct {
+		EnrollmentID uuid.UUID
+		StudentID    uuid.UUID
+		AdmissionNo  string
+		FirstName    string
+		LastName     string
+		Level        string
+		Stream       string
+		Term         string
+	}
+	var rows []enrollmentRow
+	if err := db.Table("enrollments").
+		Select("enrollments.id as enrollment_id, enrollments.student_id, students.admission_no, students.first_name, students.last_name, classes.level, classes.stream, enrollments.term").
+		Joins("JOIN students ON students.id = enrollments.student_id AND students.deleted_at IS NULL").
+		Joins("JOIN classes ON classes.id = enrollments.class_id").
+		Where("classes.school_id = ? AND enrollments.year = ? AND enrollments.status = ? AND enrollments.deleted_at IS NULL", schoolID, year, "active").
+		Where("NOT EXISTS (SELECT 1 FROM enrollments next WHERE next.student_id = enrollments.student_id AND next.year > ? AND next.deleted_at IS NULL)", year).
+		Order("students.admission_no, enrollments.enrolled_on DESC, enrollments.created_at DESC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	// Keep each student's latest enrollment in the year
+	var latest []enrollmentRow
+	seen := make(map[uuid.UUID]bool)
+	for _, row := range rows {
+		if !seen[row.StudentID] {
+			seen[row.StudentID] = true
+			latest = append(latest, row)
+		}
+	}
+	if len(latest) == 0 {
+		return nil, ErrNothingToPromote
+	}
+
+	// Final-term averages, only needed when there is a pass mark
+	averages := make(map[string]float64)
+	if passMark != nil {
+		studentIDs := make([]uuid.UUID, len(latest))
+		for i, row := range latest {
+			studentIDs[i] = row.StudentID
+		}
+		var summaries []models.TermSummary
+		if err := db.Where("student_id IN ? AND year = ?", studentIDs, year).Find(&summaries).Error; err != nil {
+			return nil, err
+		}
+		for _, summary := range summaries {
+			if summary.SubjectCount > 0 {
+				averages[summary.StudentID.String()+"|"+summary.Term] = summary.AverageScore
+			}
+		}
+	}
+
+	// Schools without the next level in a section graduate students at the
+	// level below it, e.g. S4 leavers where there is no A-level
+	var levels []string
+	if err := db.Model(&models.Class{}).Where("school_id = ?", schoolID).Distinct().Pluck("level", &levels).Error; err != nil {
+		return nil, err
+	}
+	offered := make(map[string]bool, len(levels))
+	for _, level := range levels {
+		offered[level] = true
+	}
+
+	repeaters := uuidSet(rules.RepeatStudentIDs)
+	leavers := uuidSet(rules.LeaverStudentIDs)
+	for _, row := range latest {
+		decision := PromotionDecision{
+			StudentID:    row.StudentID,
+			AdmissionNo:  row.AdmissionNo,
+			StudentName:  row.FirstName + " " + row.LastName,
+			EnrollmentID: row.EnrollmentID,
+			FromLevel:    row.Level,
+			Stream:       row.Stream,
+		}
+		if average, ok := averages[row.StudentID.String()+"|"+row.Term]; ok {
+			decision.AverageScore = &average
+		}
+
+		next, known := NextLevel(row.Level)
+		switch {
+		case leavers[row.StudentID]:
+			decision.Action = PromotionGraduate
+			decision.Reason = "On the leaver list"
+		case repeaters[row.StudentID]:
+			decision.Action = PromotionRepeat
+			decision.Reason = "On the repeat list"
+		case !known:
+			decision.Action = PromotionRepeat
+			decision.Reason = fmt.Sprintf("Level %s has no next level", row.Level)
+		case next == "":
+			decision.Action = PromotionGraduate
+			decision.Reason = fmt.Sprintf("Completed %s", row.Level)
+		case !offered[next]:
+			decision.Action = PromotionGraduate
+			decision.Reason = fmt.Sprintf("School does not offer %s", next)
+		case passMark != nil && decision.AverageScore == nil:
+			decision.Action = PromotionPromote
+			decision.Reason = fmt.Sprintf("No results for %s; promoted without a pass mark check", row.Term)
+		case passMark != nil && *decision.AverageScore < *passMark:
+			decision.Action = PromotionRepeat
+			decision.Reason = fmt.Sprintf("Average %.2f is below the pass mark %.2f", *decision.AverageScore, *passMark)
+		default:
+			decision.Action = PromotionPromote
+			decision.Reason = fmt.Sprintf("Promoted to %s", next)
+		}
+
+		switch decision.Action {
+		case PromotionPromote:
+			decision.ToLevel = next
+			plan.Promoted++
+		case PromotionRepeat:
+			decision.ToLevel = row.Level
+			plan.Repeating++
+		case PromotionGraduate:
+			plan.Graduated++
+		}
+		plan.Decisions = append(plan.Decisions, decision)
+	}
+
+	return plan, nil
+}
+
+// resolvePassMark falls back to the school's configured pass mark when none is given
+func (s *PromotionService) resolvePassMark(db *gorm.DB, schoolID uuid.UUID, requested *float64) (*float64, error) {
+	if requested != nil {
+		if *requested < 0 || *requested > 100 {
+			return nil, fmt.Errorf("%w: got %.2f", ErrInvalidPassMark, *requested)
+		}
+		return requested, nil
+	}
+
+	var school models.School
+	if err := db.First(&school, "id = ?", schoolID).Error; err != nil {
+		return nil, fmt.Errorf("school not found: %w", err)
+	}
+	if configured, ok := school.Config["promotion_pass_mark"].(float64); ok {
+		return &configured, nil
+	}
+	return nil, nil
+}
+
+// NextLevel returns the level after the given one, or "" for a terminal level.
+// known is false for levels outside the standard sequences.
+func NextLevel(level string) (next string, known bool) {
+	for _, sequence := range levelSequences {
+		for i, l := range sequence {
+			if l != level {
+				continue
+			}
+			if i == len(sequence)-1 {
+				return "", true
+			}
+			return sequence[i+1], true
+		}
+	}
+	return "", false
+}
+
+func uuidSet(ids []uuid.UUID) map[uuid.UUID]bool {
+	set := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an in-memory SQLite database with the given models migrated
+func newTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	// Every connection to :memory: is a separate database
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatalf("Failed to migrate: %v", err)
+	}
+	return db
+}
+
+func mustCreate(t *testing.T, db *gorm.DB, value interface{}) {
+	t.Helper()
+	if err := db.Create(value).Error; err != nil {
+		t.Fatalf("Failed to create %T: %v", value, err)
+	}
+}
+
+// promotionFixture is a secondary school without A-level, with S3 and S4
+// classes in 2025
+type promotionFixture struct {
+	db       *gorm.DB
+	schoolID uuid.UUID
+	classes  map[string]models.Class
+}
+
+func newPromotionFixture(t *testing.T, config models.JSONB) *promotionFixture {
+	t.Helper()
+	db := newTestDB(t, &models.School{}, &models.Class{}, &models.Student{}, &models.Enrollment{}, &models.TermSummary{}, &models.Term{})
+
+	school := models.School{Name: "Test SS", Type: "Secondary", Config: config}
+	mustCreate(t, db, &school)
+
+	f := &promotionFixture{db: db, schoolID: school.ID, classes: make(map[string]models.Class)}
+	for _, level := range []string{"S3", "S4"} {
+		class := models.Class{SchoolID: school.ID, Name: level, Level: level, Stream: "East", Year: 2025, Term: "Term3"}
+		mustCreate(t, db, &class)
+		f.classes[level] = class
+	}
+	return f
+}
+
+// enroll adds a student to the level's class, with a Term3 average when given
+func (f *promotionFixture) enroll(t *testing.T, admissionNo, level string, average *float64) uuid.UUID {
+	t.Helper()
+	student := models.Student{SchoolID: f.schoolID, AdmissionNo: admissionNo, FirstName: "Student", LastName: admissionNo}
+	mustCreate(t, f.db, &student)
+	class := f.classes[level]
+	mustCreate(t, f.db, &models.Enrollment{
+		StudentID:  student.ID,
+		ClassID:    class.ID,
+		Year:       2025,
+		Term:       "Term3",
+		Status:     "active",
+		EnrolledOn: time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if average != nil {
+		mustCreate(t, f.db, &models.TermSummary{
+			StudentID:    student.ID,
+			ClassID:      class.ID,
+			SchoolID:     f.schoolID,
+			Term:         "Term3",
+			Year:         2025,
+			Level:        level,
+			SubjectCount: 8,
+			AverageScore: *average,
+		})
+	}
+	return student.ID
+}
+
+func score(v float64) *float64 { return &v }
+
+func TestPromotionPreview(t *testing.T) {
+	f := newPromotionFixture(t, models.JSONB{"promotion_pass_mark": 50.0})
+
+	passed := f.enroll(t, "001", "S3", score(70))
+	failed := f.enroll(t, "002", "S3", score(40))
+	noResults := f.enroll(t, "003", "S3", nil)
+	candidate := f.enroll(t, "004", "S4", score(65))
+	listedRepeat := f.enroll(t, "005", "S3", score(80))
+	listedLeaver := f.enroll(t, "006", "S3", score(80))
+
+	// Already moved on to 2026, so not part of the plan
+	alreadyMoved := f.enroll(t, "007", "S3", score(75))
+	mustCreate(t, f.db, &models.Enrollment{StudentID: alreadyMoved, ClassID: f.classes["S4"].ID, Year: 2026, Term: "Term1", Status: "active"})
+
+	plan, err := NewPromotionService(f.db).Preview(f.schoolID, 2025, PromotionRules{
+		RepeatStudentIDs: []uuid.UUID{listedRepeat},
+		LeaverStudentIDs: []uuid.UUID{listedLeaver},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if plan.PassMark == nil || *plan.PassMark != 50 {
+		t.Errorf("Expected the configured pass mark 50, got %v", plan.PassMark)
+	}
+	if plan.NextYear != 2026 || plan.NextTerm != "Term1" {
+		t.Errorf("Expected Term1 2026 next, got %s %d", plan.NextTerm, plan.NextYear)
+	}
+
+	expected := map[uuid.UUID]struct{ action, toLevel string }{
+		passed:       {PromotionPromote, "S4"},
+		failed:       {PromotionRepeat, "S3"},
+		noResults:    {PromotionPromote, "S4"},
+		candidate:    {PromotionGraduate, ""},
+		listedRepeat: {PromotionRepeat, "S3"},
+		listedLeaver: {PromotionGraduate, ""},
+	}
+	if len(plan.Decisions) != len(expected) {
+		t.Fatalf("Expected %d decisions, got %d: %+v", len(expected), len(plan.Decisions), plan.Decisions)
+	}
+	for _, decision := range plan.Decisions {
+		want, ok := expected[decision.StudentID]
+		if !ok {
+			t.Errorf("Unexpected decision for %s", decision.AdmissionNo)
+			continue
+		}
+		if decision.Action != want.action || decision.ToLevel != want.toLevel {
+			t.Errorf("%s: expected %s to %q, got %s to %q (%s)", decision.AdmissionNo, want.action, want.toLevel, decision.Action, decision.ToLevel, decision.Reason)
+		}
+		if decision.Stream != "East" {
+			t.Errorf("%s: expected the stream kept, got %q", decision.AdmissionNo, decision.Stream)
+		}
+	}
+	if plan.Promoted != 2 || plan.Repeating != 2 || plan.Graduated != 2 {
+		t.Errorf("Expected 2 promoted, 2 repeating, 2 graduated, got %d, %d, %d", plan.Promoted, plan.Repeating, plan.Graduated)
+	}
+}
+
+func TestPromotionPreview_RequestedPassMark(t *testing.T) {
+	f := newPromotionFixture(t, models.JSONB{"promotion_pass_mark": 50.0})
+	student := f.enroll(t, "001", "S3", score(40))
+	service := NewPromotionService(f.db)
+
+	// A requested pass mark overrides the school's
+	plan, err := service.Preview(f.schoolID, 2025, PromotionRules{PassMark: score(30)})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if decision := plan.Decisions[0]; decision.StudentID != student || decision.Action != PromotionPromote {
+		t.Errorf("Expected promotion over a pass mark of 30, got %s (%s)", decision.Action, decision.Reason)
+	}
+
+	if _, err := service.Preview(f.schoolID, 2025, PromotionRules{PassMark: score(120)}); !errors.Is(err, ErrInvalidPassMark) {
+		t.Errorf("Expected ErrInvalidPassMark, got %v", err)
+	}
+	if _, err := service.Preview(f.schoolID, 2024, PromotionRules{}); !errors.Is(err, ErrNothingToPromote) {
+		t.Errorf("Expected ErrNothingToPromote for a year without enrollments, got %v", err)
+	}
+}
+
+func TestPromotionPreview_NoPassMark(t *testing.T) {
+	f := newPromotionFixture(t, nil)
+	f.enroll(t, "001", "S3", score(10))
+
+	plan, err := NewPromotionService(f.db).Preview(f.schoolID, 2025, PromotionRules{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if plan.PassMark != nil || plan.Promoted != 1 {
+		t.Errorf("Expected everyone promoted without a pass mark, got pass mark %v and %d promoted", plan.PassMark, plan.Promoted)
+	}
+}
+
+func TestNextLevel(t *testing.T) {
+	tests := []struct {
+		level string
+		next  string
+		known bool
+	}{
+		{"Baby", "Middle", true},
+		{"Top", "", true},
+		{"P6", "P7", true},
+		{"P7", "", true},
+		{"S4", "S5", true},
+		{"S6", "", true},
+		{"Form 1", "", false},
+	}
+
+	for _, tt := range tests {
+		next, known := NextLevel(tt.level)
+		if next != tt.next || known != tt.known {
+			t.Errorf("NextLevel(%q) = %q, %v, expected %q, %v", tt.level, next, known, tt.next, tt.known)
+		}
+	}
+}
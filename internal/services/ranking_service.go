@@ -0,0 +1,320 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// TieBreakShared gives tied students the same position and skips the next (1, 1, 3)
+	TieBreakShared = "shared"
+	// TieBreakDense gives tied students the same position without gaps (1, 1, 2)
+	TieBreakDense = "dense"
+)
+
+type RankingService struct {
+	db *gorm.DB
+}
+
+func NewRankingService(db *gorm.DB) *RankingService {
+	return &RankingService{db: db}
+}
+
+// Position is a student's place within a ranked group
+type Position struct {
+	StudentID   uuid.UUID `json:"student_id"`
+	AdmissionNo string    `json:"admission_no"`
+	StudentName string    `json:"student_name"`
+	Score       float64   `json:"score"`
+	Position    int       `json:"position"`
+	OutOf       int       `json:"out_of"`
+}
+
+// SubjectRanking ranks students within one subject by subject total
+type SubjectRanking struct {
+	SubjectID   uuid.UUID  `json:"subject_id"`
+	SubjectCode string     `json:"subject_code"`
+	SubjectName string     `json:"subject_name"`
+	Positions   []Position `json:"positions"`
+}
+
+// Ranking scopes: a single class (stream) or every stream of its level
+const (
+	RankingScopeClass = "class"
+	RankingScopeLevel = "level"
+)
+
+// ClassRanking holds overall and per-subject positions for a class and term
+type ClassRanking struct {
+	ClassID  uuid.UUID        `json:"class_id"`
+	Scope    string           `json:"scope"`
+	Level    string           `json:"level"`
+	Stream   string           `json:"stream,omitempty"`
+	Term     string           `json:"term"`
+	Year     int              `json:"year"`
+	TieBreak string           `json:"tie_break"`
+	Basis    string           `json:"basis"`
+	Overall  []Position       `json:"overall"`
+	Subjects []SubjectRanking `json:"subjects"`
+}
+
+// ResolveTieBreak returns the requested tie-break, falling back to the school's
+// configured "ranking_tie_break" and then to shared ranking
+func (s *RankingService) ResolveTieBreak(schoolID uuid.UUID, requested string) (string, error) {
+	if requested == "" {
+		var school models.School
+		if err := s.db.First(&school, "id = ?", schoolID).Error; err == nil && school.Config != nil {
+			if configured, ok := school.Config["ranking_tie_break"].(string); ok {
+				requested = configured
+			}
+		}
+	}
+
+	switch requested {
+	case "":
+		return TieBreakShared, nil
+	case TieBreakShared, TieBreakDense:
+		return requested, nil
+	default:
+		return "", fmt.Errorf("invalid tie break %q - must be %q or %q", requested, TieBreakShared, TieBreakDense)
+	}
+}
+
+// RankClass computes overall and per-subject positions for a class's term. Overall
+// positions use the PLE aggregate for P7 (lower is better), UACE points for S5/S6
+// and the average score otherwise; students without that figure are not ranked.
+func (s *RankingService) RankClass(class *models.Class, tieBreak string) (*ClassRanking, error) {
+	ranking, err := s.rank(class, []uuid.UUID{class.ID}, tieBreak, false)
+	if err != nil {
+		return nil, err
+	}
+	ranking.Scope = RankingScopeClass
+	ranking.Stream = class.Stream
+	return ranking, nil
+}
+
+// RankClassPublished ranks a class's term like RankClass but counts published
+// results only, so positions never rest on draft marks. Subject positions use
+// published results; overall positions include only students whose results for
+// the term are all published.
+func (s *RankingService) RankClassPublished(class *models.Class, tieBreak string) (*ClassRanking, error) {
+	ranking, err := s.rank(class, []uuid.UUID{class.ID}, tieBreak, true)
+	if err != nil {
+		return nil, err
+	}
+	ranking.Scope = RankingScopeClass
+	ranking.Stream = class.Stream
+	return ranking, nil
+}
+
+// RankLevel ranks the students of every stream of the class's level together
+// for the class's term
+func (s *RankingService) RankLevel(class *models.Class, tieBreak string) (*ClassRanking, error) {
+	classIDs, err := LevelClassIDs(s.db, class)
+	if err != nil {
+		return nil, err
+	}
+	ranking, err := s.rank(class, classIDs, tieBreak, false)
+	if err != nil {
+		return nil, err
+	}
+	ranking.Scope = RankingScopeLevel
+	return ranking, nil
+}
+
+// LevelClassIDs returns the classes of every stream sharing the class's level,
+// term and year
+func LevelClassIDs(db *gorm.DB, class *models.Class) ([]uuid.UUID, error) {
+	var classIDs []uuid.UUID
+	if err := db.Model(&models.Class{}).
+		Where("school_id = ? AND level = ? AND term = ? AND year = ?", class.SchoolID, class.Level, class.Term, class.Year).
+		Pluck("id", &classIDs).Error; err != nil {
+		return nil, err
+	}
+	return classIDs, nil
+}
+
+func (s *RankingService) rank(class *models.Class, classIDs []uuid.UUID, tieBreak string, publishedOnly bool) (*ClassRanking, error) {
+	type studentRow struct {
+		StudentID   uuid.UUID
+		AdmissionNo string
+		FirstName   string
+		LastName    string
+	}
+
+	var students []studentRow
+	if err := s.db.Table("enrollments").
+		Select("students.id as student_id, students.admission_no, students.first_name, students.last_name").
+		Joins("JOIN students ON students.id = enrollments.student_id AND students.deleted_at IS NULL").
+		Where("enrollments.class_id IN ? AND enrollments.deleted_at IS NULL", classIDs).
+		Scan(&students).Error; err != nil {
+		return nil, err
+	}
+
+	studentIDs := make([]uuid.UUID, len(students))
+	byID := make(map[uuid.UUID]studentRow, len(students))
+	for i, st := range students {
+		studentIDs[i] = st.StudentID
+		byID[st.StudentID] = st
+	}
+
+	newPosition := func(studentID uuid.UUID, score float64) Position {
+		st := byID[studentID]
+		return Position{
+			StudentID:   studentID,
+			AdmissionNo: st.AdmissionNo,
+			StudentName: st.FirstName + " " + st.LastName,
+			Score:       score,
+		}
+	}
+
+	ranking := &ClassRanking{
+		ClassID:  class.ID,
+		Level:    class.Level,
+		Term:     class.Term,
+		Year:     class.Year,
+		TieBreak: tieBreak,
+		Overall:  []Position{},
+		Subjects: []SubjectRanking{},
+	}
+	if len(studentIDs) == 0 {
+		return ranking, nil
+	}
+
+	// Overall positions from term summaries
+	summaryQuery := s.db.Where("student_id IN ? AND term = ? AND year = ?", studentIDs, class.Term, class.Year)
+	if publishedOnly {
+		// A summary is only final once every result behind it is published
+		summaryQuery = summaryQuery.Where("student_id NOT IN (?)", s.db.Model(&models.SubjectResult{}).
+			Select("student_id").
+			Where("student_id IN ? AND term = ? AND year = ? AND status <> ?", studentIDs, class.Term, class.Year, ResultStatusPublished))
+	}
+	var summaries []models.TermSummary
+	if err := summaryQuery.Find(&summaries).Error; err != nil {
+		return nil, err
+	}
+
+	lowerIsBetter := false
+	switch class.Level {
+	case "P7":
+		ranking.Basis = "aggregate"
+		lowerIsBetter = true
+	case "S5", "S6":
+		ranking.Basis = "points"
+	default:
+		ranking.Basis = "average_score"
+	}
+
+	for _, summary := range summaries {
+		switch {
+		case ranking.Basis == "aggregate" && summary.Aggregate != nil:
+			ranking.Overall = append(ranking.Overall, newPosition(summary.StudentID, float64(*summary.Aggregate)))
+		case ranking.Basis == "points" && summary.Points != nil:
+			ranking.Overall = append(ranking.Overall, newPosition(summary.StudentID, float64(*summary.Points)))
+		case ranking.Basis == "average_score" && summary.SubjectCount > 0:
+			ranking.Overall = append(ranking.Overall, newPosition(summary.StudentID, summary.AverageScore))
+		}
+	}
+	assignPositions(ranking.Overall, lowerIsBetter, tieBreak)
+
+	// Per-subject positions from subject totals
+	type resultRow struct {
+		StudentID    uuid.UUID
+		SubjectID    uuid.UUID
+		SubjectCode  string
+		SubjectName  string
+		DerivedCodes models.JSONB
+	}
+
+	var rows []resultRow
+	resultQuery := s.db.Table("subject_results").
+		Select("subject_results.student_id, subject_results.subject_id, subject_results.derived_codes, standard_subjects.code as subject_code, standard_subjects.name as subject_name").
+		Joins("JOIN standard_subjects ON subject_results.subject_id = standard_subjects.id").
+		Where("subject_results.student_id IN ? AND subject_results.term = ? AND subject_results.year = ? AND subject_results.deleted_at IS NULL",
+			studentIDs, class.Term, class.Year)
+	if publishedOnly {
+		resultQuery = resultQuery.Where("subject_results.status = ?", ResultStatusPublished)
+	}
+	if err := resultQuery.Order("standard_subjects.code").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	subjects := make(map[uuid.UUID]*SubjectRanking)
+	var order []uuid.UUID
+	for _, row := range rows {
+		subject, ok := subjects[row.SubjectID]
+		if !ok {
+			subject = &SubjectRanking{SubjectID: row.SubjectID, SubjectCode: row.SubjectCode, SubjectName: row.SubjectName}
+			subjects[row.SubjectID] = subject
+			order = append(order, row.SubjectID)
+		}
+		total, _ := row.DerivedCodes["total"].(float64)
+		subject.Positions = append(subject.Positions, newPosition(row.StudentID, total))
+	}
+
+	for _, subjectID := range order {
+		subject := subjects[subjectID]
+		assignPositions(subject.Positions, false, tieBreak)
+		ranking.Subjects = append(ranking.Subjects, *subject)
+	}
+
+	return ranking, nil
+}
+
+// StudentPositions extracts one student's overall and per-subject positions from a class ranking
+func (r *ClassRanking) StudentPositions(studentID uuid.UUID) (*Position, map[string]Position) {
+	var overall *Position
+	for i := range r.Overall {
+		if r.Overall[i].StudentID == studentID {
+			overall = &r.Overall[i]
+			break
+		}
+	}
+
+	subjects := make(map[string]Position)
+	for _, subject := range r.Subjects {
+		for _, p := range subject.Positions {
+			if p.StudentID == studentID {
+				subjects[subject.SubjectCode] = p
+				break
+			}
+		}
+	}
+	return overall, subjects
+}
+
+// assignPositions sorts positions best-first and numbers them using the tie-break.
+// Scores are compared to two decimal places.
+func assignPositions(positions []Position, lowerIsBetter bool, tieBreak string) {
+	rounded := func(v float64) float64 { return math.Round(v*100) / 100 }
+
+	sort.SliceStable(positions, func(i, j int) bool {
+		a, b := rounded(positions[i].Score), rounded(positions[j].Score)
+		if a == b {
+			return positions[i].StudentName < positions[j].StudentName
+		}
+		if lowerIsBetter {
+			return a < b
+		}
+		return a > b
+	})
+
+	for i := range positions {
+		positions[i].OutOf = len(positions)
+		switch {
+		case i == 0:
+			positions[i].Position = 1
+		case rounded(positions[i].Score) == rounded(positions[i-1].Score):
+			positions[i].Position = positions[i-1].Position
+		case tieBreak == TieBreakDense:
+			positions[i].Position = positions[i-1].Position + 1
+		default:
+			positions[i].Position = i + 1
+		}
+	}
+}
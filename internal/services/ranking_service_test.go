@@ -0,0 +1,51 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAssignPositions(t *testing.T) {
+	tests := []struct {
+		name          string
+		scores        []float64
+		lowerIsBetter bool
+		tieBreak      string
+		expected      []int
+	}{
+		{"no ties", []float64{70, 90, 80}, false, TieBreakShared, []int{1, 2, 3}},
+		{"shared tie at the top", []float64{90, 80, 90, 70}, false, TieBreakShared, []int{1, 1, 3, 4}},
+		{"dense tie at the top", []float64{90, 80, 90, 70}, false, TieBreakDense, []int{1, 1, 2, 3}},
+		{"shared tie in the middle", []float64{90, 80, 80, 70}, false, TieBreakShared, []int{1, 2, 2, 4}},
+		{"dense tie in the middle", []float64{90, 80, 80, 70}, false, TieBreakDense, []int{1, 2, 2, 3}},
+		{"shared tie at the bottom", []float64{90, 80, 70, 70}, false, TieBreakShared, []int{1, 2, 3, 3}},
+		{"dense tie at the bottom", []float64{90, 80, 70, 70}, false, TieBreakDense, []int{1, 2, 3, 3}},
+		{"everyone tied", []float64{60, 60, 60}, false, TieBreakShared, []int{1, 1, 1}},
+		{"tied to two decimal places", []float64{75.501, 75.499, 60}, false, TieBreakShared, []int{1, 1, 3}},
+		{"unknown tie-break shares", []float64{90, 90, 70}, false, "", []int{1, 1, 3}},
+		// P7 aggregates: 4 is the best possible, 36 the worst
+		{"lower is better", []float64{12, 4, 36}, true, TieBreakShared, []int{1, 2, 3}},
+		{"lower is better, shared tie at the top", []float64{4, 4, 9, 20}, true, TieBreakShared, []int{1, 1, 3, 4}},
+		{"lower is better, dense tie in the middle", []float64{4, 9, 9, 20}, true, TieBreakDense, []int{1, 2, 2, 3}},
+		{"lower is better, shared tie at the bottom", []float64{4, 9, 20, 20}, true, TieBreakShared, []int{1, 2, 3, 3}},
+	}
+
+	for _, tt := range tests {
+		positions := make([]Position, len(tt.scores))
+		for i, score := range tt.scores {
+			positions[i] = Position{StudentName: string(rune('A' + i)), Score: score}
+		}
+		assignPositions(positions, tt.lowerIsBetter, tt.tieBreak)
+
+		got := make([]int, len(positions))
+		for i, p := range positions {
+			got[i] = p.Position
+			if p.OutOf != len(tt.scores) {
+				t.Errorf("%s: expected out of %d, got %d", tt.name, len(tt.scores), p.OutOf)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: expected positions %v, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
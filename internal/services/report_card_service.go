@@ -0,0 +1,547 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/config"
+	"github.com/school-system/backend/internal/jobs"
+	"github.com/school-system/backend/internal/models"
+	"github.com/school-system/backend/internal/pdf"
+	"gorm.io/gorm"
+)
+
+const (
+	ReportCardStatusPending   = "pending"
+	ReportCardStatusGenerated = "generated"
+	ReportCardStatusFailed    = "failed"
+)
+
+// ReportCardComments are the end-of-term remarks printed on a report card
+type ReportCardComments struct {
+	ClassTeacher string `json:"class_teacher_comment"`
+	HeadTeacher  string `json:"head_teacher_comment"`
+}
+
+type ReportCardService struct {
+	db             *gorm.DB
+	rankingService *RankingService
+	summaryService *TermSummaryService
+	dir            string
+	logoDir        string
+}
+
+func NewReportCardService(db *gorm.DB, storage config.StorageConfig) *ReportCardService {
+	return &ReportCardService{
+		db:             db,
+		rankingService: NewRankingService(db),
+		summaryService: NewTermSummaryService(db),
+		dir:            storage.ReportCardDir(),
+		logoDir:        storage.LogoDir(),
+	}
+}
+
+// reportSubject is one row of the results table on a report card
+type reportSubject struct {
+	Code              string
+	Name              string
+	Total             float64
+	FinalGrade        string
+	ComputationReason string
+	Position          *Position
+}
+
+// reportData is everything rendered on a student's report card
+type reportData struct {
+	School      models.School
+	Student     models.Student
+	Class       models.Class
+	TeacherName string
+	Subjects    []reportSubject
+	Summary     *models.TermSummary
+	Position    *Position
+	Comments    ReportCardComments
+}
+
+// Generate renders a student's report card for the class's term and records it on
+// the ReportCard row, which moves from pending to generated, or to failed with the
+// error in Meta. Empty comments keep those saved on a previous generation.
+// Positions come from ranking, or from ranking the class afresh when it is nil;
+// class jobs rank the class once and share it.
+func (s *ReportCardService) Generate(studentID uuid.UUID, class *models.Class, generatedBy uuid.UUID, comments ReportCardComments, ranking *ClassRanking) (*models.ReportCard, error) {
+	var card models.ReportCard
+	err := s.db.Where("student_id = ? AND term = ? AND year = ?", studentID, class.Term, class.Year).First(&card).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if card.Meta == nil {
+		card.Meta = models.JSONB{}
+	}
+	if comments.ClassTeacher == "" {
+		comments.ClassTeacher, _ = card.Meta["class_teacher_comment"].(string)
+	}
+	if comments.HeadTeacher == "" {
+		comments.HeadTeacher, _ = card.Meta["head_teacher_comment"].(string)
+	}
+	card.Meta["class_teacher_comment"] = comments.ClassTeacher
+	card.Meta["head_teacher_comment"] = comments.HeadTeacher
+	delete(card.Meta, "error")
+
+	card.StudentID = studentID
+	card.ClassID = class.ID
+	card.Term = class.Term
+	card.Year = class.Year
+	card.Status = ReportCardStatusPending
+	if err := s.db.Save(&card).Error; err != nil {
+		return nil, fmt.Errorf("failed to save report card: %w", err)
+	}
+
+	path, err := s.render(&card, class, comments, ranking)
+	if err != nil {
+		card.Status = ReportCardStatusFailed
+		card.Meta["error"] = err.Error()
+		if saveErr := s.db.Save(&card).Error; saveErr != nil {
+			return &card, fmt.Errorf("%v (and failed to record failure: %v)", err, saveErr)
+		}
+		return &card, err
+	}
+
+	now := time.Now()
+	card.Status = ReportCardStatusGenerated
+	card.PDFURL = fmt.Sprintf("/api/v1/report-cards/%s/download", card.ID)
+	card.Meta["file"] = path
+	card.GeneratedBy = &generatedBy
+	card.GeneratedAt = &now
+	if err := s.db.Save(&card).Error; err != nil {
+		return &card, fmt.Errorf("failed to save report card: %w", err)
+	}
+
+	return &card, nil
+}
+
+// FilePath returns the stored PDF for a generated report card
+func (s *ReportCardService) FilePath(card *models.ReportCard) (string, error) {
+	if card.Status != ReportCardStatusGenerated {
+		return "", fmt.Errorf("report card is %s", card.Status)
+	}
+	path, _ := card.Meta["file"].(string)
+	if path == "" {
+		return "", errors.New("report card has no stored file")
+	}
+	return path, nil
+}
+
+func (s *ReportCardService) render(card *models.ReportCard, class *models.Class, comments ReportCardComments, ranking *ClassRanking) (string, error) {
+	data, err := s.loadData(card.StudentID, class, ranking)
+	if err != nil {
+		return "", err
+	}
+	data.Comments = comments
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create report card directory: %w", err)
+	}
+	path := filepath.Join(s.dir, card.ID.String()+".pdf")
+	if err := os.WriteFile(path, renderReportCard(data, s.loadLogo(data.School.LogoURL)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write report card: %w", err)
+	}
+	return path, nil
+}
+
+func (s *ReportCardService) loadData(studentID uuid.UUID, class *models.Class, ranking *ClassRanking) (*reportData, error) {
+	data := &reportData{Class: *class}
+
+	if err := s.db.First(&data.Student, "id = ?", studentID).Error; err != nil {
+		return nil, fmt.Errorf("student not found: %w", err)
+	}
+	if err := s.db.First(&data.School, "id = ?", class.SchoolID).Error; err != nil {
+		return nil, fmt.Errorf("school not found: %w", err)
+	}
+	if class.TeacherID != nil {
+		var teacher models.User
+		if err := s.db.First(&teacher, "id = ?", *class.TeacherID).Error; err == nil {
+			data.TeacherName = teacher.FullName
+		}
+	}
+
+	type resultRow struct {
+		Code              string
+		Name              string
+		FinalGrade        string
+		ComputationReason string
+		DerivedCodes      models.JSONB
+		Status            string
+	}
+
+	var rows []resultRow
+	if err := s.db.Table("subject_results").
+		Select("standard_subjects.code, standard_subjects.name, subject_results.final_grade, subject_results.computation_reason, subject_results.derived_codes, subject_results.status").
+		Joins("JOIN standard_subjects ON subject_results.subject_id = standard_subjects.id").
+		Where("subject_results.student_id = ? AND subject_results.term = ? AND subject_results.year = ? AND subject_results.deleted_at IS NULL",
+			studentID, class.Term, class.Year).
+		Order("standard_subjects.code").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no results for %s %d", class.Term, class.Year)
+	}
+
+	// Only published results may appear on a report card
+	var unpublished []string
+	for _, row := range rows {
+		if row.Status != ResultStatusPublished {
+			unpublished = append(unpublished, row.Code)
+		}
+	}
+	if len(unpublished) > 0 {
+		return nil, fmt.Errorf("results not yet published for %s", strings.Join(unpublished, ", "))
+	}
+
+	// Worked out afresh from the published results rather than read from the
+	// stored summary, which also counts results still in the workflow
+	summary, err := s.summaryService.PublishedSummary(studentID, class)
+	if err != nil {
+		return nil, err
+	}
+	data.Summary = summary
+
+	var subjectPositions map[string]Position
+	if ranking == nil {
+		ranking = s.publishedRanking(class)
+	}
+	if ranking != nil {
+		data.Position, subjectPositions = ranking.StudentPositions(studentID)
+	}
+
+	for _, row := range rows {
+		subject := reportSubject{
+			Code:              row.Code,
+			Name:              row.Name,
+			FinalGrade:        row.FinalGrade,
+			ComputationReason: row.ComputationReason,
+		}
+		if total, ok := row.DerivedCodes["total"].(float64); ok {
+			subject.Total = total
+		}
+		if p, ok := subjectPositions[row.Code]; ok {
+			subject.Position = &p
+		}
+		data.Subjects = append(data.Subjects, subject)
+	}
+
+	return data, nil
+}
+
+// publishedRanking ranks a class on its published results, as positions are
+// printed on report cards, using the school's tie-break. It returns nil if the
+// class cannot be ranked; cards are then printed without positions.
+func (s *ReportCardService) publishedRanking(class *models.Class) *ClassRanking {
+	tieBreak, err := s.rankingService.ResolveTieBreak(class.SchoolID, "")
+	if err != nil {
+		tieBreak = TieBreakShared
+	}
+	ranking, err := s.rankingService.RankClassPublished(class, tieBreak)
+	if err != nil {
+		log.Printf("failed to rank class %s: %v", class.ID, err)
+		return nil
+	}
+	return ranking
+}
+
+// renderReportCard lays out a one-page A4 report card, continuing onto further
+// pages if the results table is long. A nil or unusable logo is left off.
+func renderReportCard(data *reportData, logo image.Image) []byte {
+	const margin = 40.0
+	doc := pdf.New()
+	doc.AddPage()
+
+	y := 50.0
+	if logo != nil {
+		if err := doc.Image(logo, margin, 30, 60, 60); err != nil {
+			log.Printf("failed to embed logo for school %s: %v", data.School.ID, err)
+		}
+	}
+	doc.TextCentered(y, 16, true, strings.ToUpper(data.School.Name))
+	y += 16
+	if data.School.Address != "" {
+		doc.TextCentered(y, 9, false, data.School.Address)
+		y += 12
+	}
+	if data.School.Motto != "" {
+		doc.TextCentered(y, 9, false, "Motto: "+data.School.Motto)
+		y += 12
+	}
+	y = max(y, 95)
+	doc.TextCentered(y, 12, true, fmt.Sprintf("REPORT CARD - %s %d", data.Class.Term, data.Class.Year))
+	y += 8
+	doc.Line(margin, y, pdf.PageWidth-margin, y)
+	y += 18
+
+	doc.Text(margin, y, 10, true, "Name:")
+	doc.Text(margin+45, y, 10, false, data.Student.FirstName+" "+data.Student.LastName)
+	doc.Text(320, y, 10, true, "Admission No:")
+	doc.Text(400, y, 10, false, data.Student.AdmissionNo)
+	y += 15
+	doc.Text(margin, y, 10, true, "Class:")
+	doc.Text(margin+45, y, 10, false, data.Class.Name)
+	if data.Position != nil {
+		doc.Text(320, y, 10, true, "Position:")
+		doc.Text(400, y, 10, false, fmt.Sprintf("%d out of %d", data.Position.Position, data.Position.OutOf))
+	}
+	y += 22
+
+	// Results table
+	columns := []struct {
+		title string
+		x     float64
+	}{
+		{"Subject", margin + 4},
+		{"Score", 200},
+		{"Grade", 240},
+		{"Pos.", 280},
+		{"Remarks", 320},
+	}
+	header := func() {
+		doc.Line(margin, y-12, pdf.PageWidth-margin, y-12)
+		for _, col := range columns {
+			doc.Text(col.x, y, 9, true, col.title)
+		}
+		y += 5
+		doc.Line(margin, y, pdf.PageWidth-margin, y)
+		y += 12
+	}
+	header()
+
+	reasonWidth := pdf.PageWidth - margin - 324
+	for _, subject := range data.Subjects {
+		reasons := pdf.Wrap(subject.ComputationReason, 7, reasonWidth)
+		if len(reasons) == 0 {
+			reasons = []string{""}
+		}
+		if y+float64(len(reasons))*9 > pdf.PageHeight-150 {
+			doc.AddPage()
+			y = 60
+			header()
+		}
+
+		doc.Text(columns[0].x, y, 9, false, subject.Name)
+		doc.Text(columns[1].x, y, 9, false, fmt.Sprintf("%.1f", subject.Total))
+		doc.Text(columns[2].x, y, 9, true, subject.FinalGrade)
+		if subject.Position != nil {
+			doc.Text(columns[3].x, y, 9, false, fmt.Sprintf("%d/%d", subject.Position.Position, subject.Position.OutOf))
+		}
+		for i, line := range reasons {
+			doc.Text(columns[4].x, y+float64(i)*9, 7, false, line)
+		}
+		y += float64(len(reasons))*9 + 5
+		doc.Line(margin, y-9, pdf.PageWidth-margin, y-9)
+	}
+	y += 8
+
+	// Overall standing
+	if data.Summary != nil {
+		overall := fmt.Sprintf("Subjects: %d    Average: %.2f", data.Summary.SubjectCount, data.Summary.AverageScore)
+		if data.Summary.Aggregate != nil {
+			overall += fmt.Sprintf("    Aggregate: %d    Division: %s", *data.Summary.Aggregate, data.Summary.Division)
+		}
+		if data.Summary.Points != nil {
+			overall += fmt.Sprintf("    Points: %d", *data.Summary.Points)
+		}
+		doc.Text(margin, y, 10, true, overall)
+		y += 25
+	}
+
+	// Comments
+	comment := func(title, text, signatory string) {
+		doc.Text(margin, y, 10, true, title)
+		y += 13
+		for _, line := range pdf.Wrap(text, 9, pdf.PageWidth-2*margin) {
+			doc.Text(margin, y, 9, false, line)
+			y += 11
+		}
+		y += 10
+		doc.Text(margin, y, 9, false, "Signature: ______________________")
+		if signatory != "" {
+			doc.Text(320, y, 9, false, signatory)
+		}
+		y += 22
+	}
+	comment("Class Teacher's Comment", data.Comments.ClassTeacher, data.TeacherName)
+	comment("Head Teacher's Comment", data.Comments.HeadTeacher, "")
+
+	doc.Text(margin, pdf.PageHeight-25, 7, false, fmt.Sprintf("Generated on %s", time.Now().Format("02 Jan 2006")))
+
+	return doc.Bytes()
+}
+
+// loadLogo reads a school logo uploaded through /upload/logo; other URLs are skipped
+func (s *ReportCardService) loadLogo(logoURL string) image.Image {
+	if !strings.HasPrefix(logoURL, "/logos/") {
+		return nil
+	}
+	f, err := os.Open(filepath.Join(s.logoDir, filepath.Base(logoURL)))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil
+	}
+	return img
+}
+
+// JobTypeGenerateReportCards renders report cards for every student in a class
+const JobTypeGenerateReportCards = "generate_report_cards"
+
+// reportCardWorkers bounds how many report cards a class job renders at once
+const reportCardWorkers = 4
+
+// ClassArchivePath is where a class report card job stores its combined ZIP
+func (s *ReportCardService) ClassArchivePath(jobID uuid.UUID) string {
+	return filepath.Join(s.dir, "jobs", jobID.String()+".zip")
+}
+
+// HandleClassJob is the job handler for JobTypeGenerateReportCards. Payload holds
+// class_id and generated_by; per-student progress is written to the job's Result
+// as each card finishes and the generated PDFs are bundled into one ZIP.
+func (s *ReportCardService) HandleClassJob(ctx context.Context, job *models.Job) (models.JSONB, error) {
+	classID, err := uuid.Parse(fmt.Sprint(job.Payload["class_id"]))
+	if err != nil {
+		return nil, fmt.Errorf("invalid class_id in payload: %w", err)
+	}
+	generatedBy, _ := uuid.Parse(fmt.Sprint(job.Payload["generated_by"]))
+
+	var class models.Class
+	if err := s.db.First(&class, "id = ?", classID).Error; err != nil {
+		return nil, fmt.Errorf("class not found: %w", err)
+	}
+
+	var students []models.Student
+	if err := s.db.Joins("JOIN enrollments ON enrollments.student_id = students.id AND enrollments.deleted_at IS NULL").
+		Where("enrollments.class_id = ?", class.ID).
+		Order("students.admission_no").
+		Find(&students).Error; err != nil {
+		return nil, err
+	}
+
+	progress := make(map[string]interface{}, len(students))
+	for _, student := range students {
+		progress[student.ID.String()] = map[string]interface{}{"status": ReportCardStatusPending}
+	}
+	result := models.JSONB{"total": len(students), "completed": 0, "failed": 0, "students": progress}
+
+	// Every card shares one ranking of the class
+	ranking := s.publishedRanking(&class)
+
+	var mu sync.Mutex
+	files := make(map[uuid.UUID]string)
+	record := func(student models.Student, card *models.ReportCard, genErr error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		entry := map[string]interface{}{
+			"admission_no": student.AdmissionNo,
+			"name":         student.FirstName + " " + student.LastName,
+		}
+		if card != nil {
+			entry["report_card_id"] = card.ID
+		}
+		if genErr != nil {
+			entry["status"] = ReportCardStatusFailed
+			entry["error"] = genErr.Error()
+			result["failed"] = result["failed"].(int) + 1
+		} else {
+			entry["status"] = ReportCardStatusGenerated
+			result["completed"] = result["completed"].(int) + 1
+			files[student.ID] = card.Meta["file"].(string)
+		}
+		progress[student.ID.String()] = entry
+
+		if err := jobs.UpdateResult(s.db, job.ID, result); err != nil {
+			log.Printf("failed to record progress for job %s: %v", job.ID, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, reportCardWorkers)
+	for _, student := range students {
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(student models.Student) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			card, err := s.Generate(student.ID, &class, generatedBy, ReportCardComments{}, ranking)
+			record(student, card, err)
+		}(student)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	if len(files) == 0 {
+		return result, errors.New("no report cards were generated")
+	}
+
+	if err := writeArchive(s.ClassArchivePath(job.ID), students, files); err != nil {
+		return result, err
+	}
+	result["download_url"] = fmt.Sprintf("/api/v1/jobs/%s/download", job.ID)
+
+	return result, nil
+}
+
+// writeArchive bundles generated report cards into a ZIP named by admission number
+func writeArchive(path string, students []models.Student, files map[uuid.UUID]string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+	for _, student := range students {
+		file, ok := files[student.ID]
+		if !ok {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read report card for %s: %w", student.AdmissionNo, err)
+		}
+		name := fmt.Sprintf("%s-%s-%s.pdf", student.AdmissionNo, student.FirstName, student.LastName)
+		w, err := zw.Create(strings.ReplaceAll(name, "/", "-"))
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(content); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return out.Close()
+}
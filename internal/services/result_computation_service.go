@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/grading"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoGrader = errors.New("no grader available for level")
+	ErrNoMarks  = errors.New("no marks recorded")
+)
+
+type ResultComputationService struct {
+	db          *gorm.DB
+	ruleService *GradingRuleService
+}
+
+func NewResultComputationService(db *gorm.DB) *ResultComputationService {
+	return &ResultComputationService{
+		db:          db,
+		ruleService: NewGradingRuleService(db),
+	}
+}
+
+// WithContext returns a copy of the service whose queries run with ctx, so
+// results computed on behalf of a request are audited against its user
+func (s *ResultComputationService) WithContext(ctx context.Context) *ResultComputationService {
+	scoped := *s
+	scoped.db = s.db.WithContext(ctx)
+	return &scoped
+}
+
+// AggregateMarks sums a student's marks for a class and subject into grading
+// components per assessment type. Exam marks are also grouped by the paper
+// recorded in Assessment.Meta for UACE grading.
+func (s *ResultComputationService) AggregateMarks(studentID, subjectID, classID uuid.UUID) (*grading.Input, error) {
+	var marks []models.Mark
+	if err := s.db.Preload("Assessment").
+		Joins("JOIN assessments ON assessments.id = marks.assessment_id AND assessments.deleted_at IS NULL").
+		Where("marks.student_id = ? AND assessments.class_id = ? AND assessments.subject_id = ?", studentID, classID, subjectID).
+		Find(&marks).Error; err != nil {
+		return nil, err
+	}
+
+	if len(marks) == 0 {
+		return nil, ErrNoMarks
+	}
+
+	ca := grading.Component{Name: "ca"}
+	exam := grading.Component{Name: "exam"}
+	paperScores := make(map[int]float64)
+	paperMax := make(map[int]float64)
+
+	for _, mark := range marks {
+		assessment := mark.Assessment
+		switch assessment.AssessmentType {
+		case "ca":
+			ca.Score += mark.MarksObtained
+			ca.Max += float64(assessment.MaxMarks)
+		case "exam":
+			exam.Score += mark.MarksObtained
+			exam.Max += float64(assessment.MaxMarks)
+
+			paper := assessmentPaper(assessment)
+			paperScores[paper] += mark.MarksObtained
+			paperMax[paper] += float64(assessment.MaxMarks)
+		}
+	}
+
+	input := &grading.Input{Components: []grading.Component{ca, exam}}
+
+	papers := make([]int, 0, len(paperScores))
+	for paper := range paperScores {
+		papers = append(papers, paper)
+	}
+	sort.Ints(papers)
+	for _, paper := range papers {
+		input.Papers = append(input.Papers, paperScores[paper]/paperMax[paper]*100)
+	}
+
+	return input, nil
+}
+
+// Grade dispatches marks to the grader registered for the level, then applies
+// the school's grading rule for the level when one is configured. Advanced (UACE)
+// rules keep the UNEB paper-code grade; their bands only supply points.
+func (s *ResultComputationService) Grade(schoolID uuid.UUID, level string, input *grading.Input) (grading.GradeResult, error) {
+	grader, ok := grading.GetGrader(level)
+	if !ok {
+		return grading.GradeResult{}, fmt.Errorf("%w %s", ErrNoGrader, level)
+	}
+
+	graded, err := grader.Grade(*input)
+	if err != nil {
+		return graded, err
+	}
+
+	rules, err := s.ruleService.LoadRuleSet(schoolID, level)
+	if err != nil {
+		return grading.GradeResult{}, err
+	}
+	if rules == nil || !rules.HasRanges() || rules.Type == "advanced" {
+		return graded, nil
+	}
+	return rules.Apply(graded)
+}
+
+// GradeRawMarks grades marks submitted directly as raw_marks. It accepts
+// ca/ca_max/exam/exam_max components, a papers list for UACE, an ECCE
+// descriptor, or a bare total percentage which is applied to both components
+// and, at A-level, to every paper.
+func (s *ResultComputationService) GradeRawMarks(schoolID uuid.UUID, level string, subject *models.StandardSubject, raw models.JSONB) (grading.GradeResult, error) {
+	return s.Grade(schoolID, level, rawMarksInput(level, subject, raw))
+}
+
+// rawMarksInput turns submitted raw_marks into grader input
+func rawMarksInput(level string, subject *models.StandardSubject, raw models.JSONB) *grading.Input {
+	input := &grading.Input{Subsidiary: isSubsidiary(subject)}
+
+	if exam, ok := raw["exam"].(float64); ok {
+		input.Components = []grading.Component{
+			{Name: "ca", Score: jsonNumber(raw, "ca", 0), Max: jsonNumber(raw, "ca_max", 100)},
+			{Name: "exam", Score: exam, Max: jsonNumber(raw, "exam_max", 100)},
+		}
+	} else if total, ok := raw["total"].(float64); ok {
+		input.Components = []grading.Component{
+			{Name: "ca", Score: total, Max: 100},
+			{Name: "exam", Score: total, Max: 100},
+		}
+		if level == "S5" || level == "S6" {
+			for i := 0; i < advancedPaperCount(subject, input.Subsidiary); i++ {
+				input.Papers = append(input.Papers, total)
+			}
+		}
+	}
+
+	if papers, ok := raw["papers"].([]interface{}); ok {
+		input.Papers = nil
+		for _, p := range papers {
+			if mark, ok := p.(float64); ok {
+				input.Papers = append(input.Papers, mark)
+			}
+		}
+	}
+
+	if descriptor, ok := raw["descriptor"].(string); ok {
+		input.Descriptor = descriptor
+	}
+
+	return input
+}
+
+// advancedPaperCount is how many papers a UACE subject sits: one for a
+// subsidiary, otherwise the subject's paper count and at least two
+func advancedPaperCount(subject *models.StandardSubject, subsidiary bool) int {
+	if subsidiary {
+		return 1
+	}
+	if subject != nil && subject.Papers > 2 {
+		return subject.Papers
+	}
+	return 2
+}
+
+// ComputeSubjectResult grades a student's recorded marks for a subject in a class
+// and stores the outcome on the matching SubjectResult
+func (s *ResultComputationService) ComputeSubjectResult(studentID, subjectID uuid.UUID, class *models.Class) (*models.SubjectResult, error) {
+	var subject models.StandardSubject
+	if err := s.db.First(&subject, "id = ?", subjectID).Error; err != nil {
+		return nil, fmt.Errorf("subject not found: %w", err)
+	}
+
+	input, err := s.AggregateMarks(studentID, subjectID, class.ID)
+	if err != nil {
+		return nil, err
+	}
+	input.Subsidiary = isSubsidiary(&subject)
+
+	graded, err := s.Grade(class.SchoolID, class.Level, input)
+	if err != nil {
+		return nil, err
+	}
+
+	var result models.SubjectResult
+	err = s.db.Where("student_id = ? AND subject_id = ? AND term = ? AND year = ?",
+		studentID, subjectID, class.Term, class.Year).First(&result).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if !ResultEditable(&result) {
+		return nil, fmt.Errorf("result is %s and can no longer be recomputed", result.Status)
+	}
+
+	result.StudentID = studentID
+	result.SubjectID = subjectID
+	result.ClassID = class.ID
+	result.Term = class.Term
+	result.Year = class.Year
+	result.SchoolID = class.SchoolID
+	result.Status = ResultStatusDraft
+	ca, _ := input.Component("ca")
+	exam, _ := input.Component("exam")
+	result.RawMarks = models.JSONB{
+		"ca":       ca.Score,
+		"ca_max":   ca.Max,
+		"exam":     exam.Score,
+		"exam_max": exam.Max,
+		"papers":   input.Papers,
+	}
+	s.ApplyGrade(&result, graded)
+
+	if err := s.db.Save(&result).Error; err != nil {
+		return nil, fmt.Errorf("failed to save result: %w", err)
+	}
+
+	return &result, nil
+}
+
+// ApplyGrade copies a grader's output onto a result that is about to be saved
+func (s *ResultComputationService) ApplyGrade(result *models.SubjectResult, graded grading.GradeResult) {
+	derived := models.JSONB{"total": graded.Total}
+	if graded.PaperCodes != nil {
+		derived["paper_codes"] = graded.PaperCodes
+	}
+
+	result.FinalGrade = graded.FinalGrade
+	result.DerivedCodes = derived
+	result.ComputationReason = graded.ComputationReason
+	result.RuleVersionHash = graded.RuleVersionHash
+}
+
+// isSubsidiary reports whether an A-level subject is a single-paper subsidiary (GP, ICT, Sub-Math)
+func isSubsidiary(subject *models.StandardSubject) bool {
+	return subject != nil && (subject.Level == "S5" || subject.Level == "S6") && subject.Papers == 1
+}
+
+func assessmentPaper(assessment *models.Assessment) int {
+	if assessment.Meta != nil {
+		if paper, ok := assessment.Meta["paper"].(float64); ok {
+			return int(paper)
+		}
+	}
+	return 1
+}
+
+func jsonNumber(raw models.JSONB, key string, fallback float64) float64 {
+	if v, ok := raw[key].(float64); ok {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/school-system/backend/internal/grading"
+	"github.com/school-system/backend/internal/models"
+)
+
+func TestRawMarksInput_AdvancedTotal(t *testing.T) {
+	uace, _ := grading.GetGrader("S6")
+
+	tests := []struct {
+		name     string
+		subject  *models.StandardSubject
+		papers   int
+		expected string
+	}{
+		{"principal", &models.StandardSubject{Level: "S6", Papers: 2}, 2, "A"},
+		{"three papers", &models.StandardSubject{Level: "S6", Papers: 3}, 3, "A"},
+		{"subsidiary", &models.StandardSubject{Level: "S6", Papers: 1}, 1, "O"},
+		{"unknown subject", nil, 2, "A"},
+	}
+
+	for _, tt := range tests {
+		input := rawMarksInput("S6", tt.subject, models.JSONB{"total": 80.0})
+		if len(input.Papers) != tt.papers {
+			t.Fatalf("%s: expected %d papers, got %v", tt.name, tt.papers, input.Papers)
+		}
+
+		result, err := uace.Grade(*input)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if result.FinalGrade != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, result.FinalGrade)
+		}
+	}
+}
+
+func TestRawMarksInput_OrdinaryTotal(t *testing.T) {
+	input := rawMarksInput("S2", &models.StandardSubject{Level: "S2", Papers: 2}, models.JSONB{"total": 72.0})
+	if len(input.Papers) != 0 {
+		t.Errorf("Expected no papers below A-level, got %v", input.Papers)
+	}
+	if len(input.Components) != 2 {
+		t.Errorf("Expected ca and exam components, got %v", input.Components)
+	}
+}
+
+func TestRawMarksInput_ExplicitPapers(t *testing.T) {
+	raw := models.JSONB{"total": 80.0, "papers": []interface{}{40.0, 76.0, 71.0}}
+	input := rawMarksInput("S5", &models.StandardSubject{Level: "S5", Papers: 2}, raw)
+	if len(input.Papers) != 3 || input.Papers[0] != 40 {
+		t.Errorf("Expected the submitted papers to win over total, got %v", input.Papers)
+	}
+}
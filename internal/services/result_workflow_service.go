@@ -0,0 +1,124 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// Result statuses, in workflow order
+const (
+	ResultStatusDraft     = "draft"
+	ResultStatusSubmitted = "submitted"
+	ResultStatusApproved  = "approved"
+	ResultStatusPublished = "published"
+)
+
+// Workflow actions that move a result between statuses
+const (
+	ResultActionSubmit  = "submit"
+	ResultActionApprove = "approve"
+	ResultActionReturn  = "return"
+	ResultActionPublish = "publish"
+)
+
+// ResultApproverRoles may approve, return and publish results
+var ResultApproverRoles = []string{"system_admin", "school_admin", "head_of_department"}
+
+var (
+	ErrInvalidTransition = errors.New("invalid result transition")
+	ErrNotApprover       = errors.New("only a head of department or school admin can approve, return or publish results")
+	ErrReasonRequired    = errors.New("a reason is required to return a result")
+)
+
+// resultTransitions lists, per action, the statuses it applies to and the status it leads to
+var resultTransitions = map[string]struct {
+	from     []string
+	to       string
+	approver bool
+}{
+	ResultActionSubmit:  {from: []string{ResultStatusDraft}, to: ResultStatusSubmitted},
+	ResultActionApprove: {from: []string{ResultStatusSubmitted}, to: ResultStatusApproved, approver: true},
+	ResultActionReturn:  {from: []string{ResultStatusSubmitted, ResultStatusApproved}, to: ResultStatusDraft, approver: true},
+	ResultActionPublish: {from: []string{ResultStatusApproved}, to: ResultStatusPublished, approver: true},
+}
+
+// IsResultApprover reports whether a role may approve, return and publish results
+func IsResultApprover(role string) bool {
+	for _, r := range ResultApproverRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ResultEditable reports whether a result's marks may still be changed
+func ResultEditable(result *models.SubjectResult) bool {
+	return result.Status == "" || result.Status == ResultStatusDraft
+}
+
+type ResultWorkflowService struct {
+	db *gorm.DB
+}
+
+func NewResultWorkflowService(db *gorm.DB) *ResultWorkflowService {
+	return &ResultWorkflowService{db: db}
+}
+
+// Transition applies a workflow action to results in one transaction. Every
+// result must be in a status the action applies to; otherwise nothing changes.
+// The audit callbacks record each status change along with its reason.
+func (s *ResultWorkflowService) Transition(results []models.SubjectResult, action, reason, actorRole string) ([]models.SubjectResult, error) {
+	transition, ok := resultTransitions[action]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown action %q", ErrInvalidTransition, action)
+	}
+	if transition.approver && !IsResultApprover(actorRole) {
+		return nil, ErrNotApprover
+	}
+	if action == ResultActionReturn && reason == "" {
+		return nil, ErrReasonRequired
+	}
+
+	for _, result := range results {
+		status := result.Status
+		if status == "" {
+			status = ResultStatusDraft
+		}
+		if !containsString(transition.from, status) {
+			return nil, fmt.Errorf("%w: cannot %s a %s result", ErrInvalidTransition, action, status)
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for i := range results {
+			result := &results[i]
+			result.Status = transition.to
+			result.StatusReason = reason
+			if err := tx.Model(result).Updates(map[string]interface{}{
+				"status":        result.Status,
+				"status_reason": result.StatusReason,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+func createResult(t *testing.T, db *gorm.DB, status string) models.SubjectResult {
+	t.Helper()
+	result := models.SubjectResult{
+		StudentID: uuid.New(),
+		SubjectID: uuid.New(),
+		ClassID:   uuid.New(),
+		SchoolID:  uuid.New(),
+		Term:      "Term1",
+		Year:      2025,
+		Status:    status,
+	}
+	mustCreate(t, db, &result)
+	return result
+}
+
+func TestResultTransition_Matrix(t *testing.T) {
+	db := newTestDB(t, &models.SubjectResult{})
+	service := NewResultWorkflowService(db)
+
+	statuses := []string{ResultStatusDraft, ResultStatusSubmitted, ResultStatusApproved, ResultStatusPublished}
+	expected := map[string]map[string]string{
+		ResultActionSubmit:  {ResultStatusDraft: ResultStatusSubmitted},
+		ResultActionApprove: {ResultStatusSubmitted: ResultStatusApproved},
+		ResultActionReturn:  {ResultStatusSubmitted: ResultStatusDraft, ResultStatusApproved: ResultStatusDraft},
+		ResultActionPublish: {ResultStatusApproved: ResultStatusPublished},
+	}
+
+	for action, allowed := range expected {
+		for _, from := range statuses {
+			result := createResult(t, db, from)
+			updated, err := service.Transition([]models.SubjectResult{result}, action, "Check the marks", "school_admin")
+
+			to, ok := allowed[from]
+			if !ok {
+				if !errors.Is(err, ErrInvalidTransition) {
+					t.Errorf("%s a %s result: expected ErrInvalidTransition, got %v", action, from, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s a %s result: unexpected error: %v", action, from, err)
+				continue
+			}
+
+			var stored models.SubjectResult
+			if err := db.First(&stored, "id = ?", result.ID).Error; err != nil {
+				t.Fatalf("Failed to load result: %v", err)
+			}
+			if stored.Status != to || updated[0].Status != to {
+				t.Errorf("%s a %s result: expected %s, got %s", action, from, to, stored.Status)
+			}
+		}
+	}
+
+	if _, err := service.Transition([]models.SubjectResult{createResult(t, db, "")}, "archive", "", "school_admin"); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("Expected ErrInvalidTransition for an unknown action, got %v", err)
+	}
+	// Results from before the workflow count as drafts
+	if _, err := service.Transition([]models.SubjectResult{createResult(t, db, "")}, ResultActionSubmit, "", "teacher"); err != nil {
+		t.Errorf("Expected a result without a status to be submittable, got %v", err)
+	}
+}
+
+func TestResultTransition_ApproverRoles(t *testing.T) {
+	db := newTestDB(t, &models.SubjectResult{})
+	service := NewResultWorkflowService(db)
+
+	for _, role := range []string{"system_admin", "school_admin", "head_of_department", "teacher", ""} {
+		result := createResult(t, db, ResultStatusSubmitted)
+		_, err := service.Transition([]models.SubjectResult{result}, ResultActionApprove, "", role)
+		if IsResultApprover(role) {
+			if err != nil {
+				t.Errorf("%q: expected approval, got %v", role, err)
+			}
+		} else if !errors.Is(err, ErrNotApprover) {
+			t.Errorf("%q: expected ErrNotApprover, got %v", role, err)
+		}
+	}
+
+	// Teachers can still submit their own results
+	if _, err := service.Transition([]models.SubjectResult{createResult(t, db, ResultStatusDraft)}, ResultActionSubmit, "", "teacher"); err != nil {
+		t.Errorf("Expected a teacher to submit, got %v", err)
+	}
+}
+
+func TestResultTransition_AllOrNothing(t *testing.T) {
+	db := newTestDB(t, &models.SubjectResult{})
+	service := NewResultWorkflowService(db)
+
+	draft := createResult(t, db, ResultStatusDraft)
+	published := createResult(t, db, ResultStatusPublished)
+	if _, err := service.Transition([]models.SubjectResult{draft, published}, ResultActionSubmit, "", "teacher"); !errors.Is(err, ErrInvalidTransition) {
+		t.Fatalf("Expected ErrInvalidTransition, got %v", err)
+	}
+
+	var stored models.SubjectResult
+	db.First(&stored, "id = ?", draft.ID)
+	if stored.Status != ResultStatusDraft {
+		t.Errorf("Expected the draft left unchanged, got %s", stored.Status)
+	}
+}
+
+func TestResultTransition_ReturnNeedsReason(t *testing.T) {
+	db := newAuditedTestDB(t, &models.SubjectResult{})
+	service := NewResultWorkflowService(db)
+
+	result := createResult(t, db, ResultStatusSubmitted)
+	if _, err := service.Transition([]models.SubjectResult{result}, ResultActionReturn, "", "head_of_department"); !errors.Is(err, ErrReasonRequired) {
+		t.Errorf("Expected ErrReasonRequired, got %v", err)
+	}
+	if _, err := service.Transition([]models.SubjectResult{result}, ResultActionReturn, "Paper 2 missing", "head_of_department"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	// The change is audited once, with its reason
+	var logs []models.AuditLog
+	db.Where("resource_id = ? AND action <> ?", result.ID, "CREATE").Find(&logs)
+	if len(logs) != 1 {
+		t.Fatalf("Expected one audit entry for the return, got %d", len(logs))
+	}
+	if logs[0].Before["status"] != ResultStatusSubmitted || logs[0].After["status"] != ResultStatusDraft || logs[0].After["status_reason"] != "Paper 2 missing" {
+		t.Errorf("Expected submitted -> draft with the reason, got %v -> %v", logs[0].Before, logs[0].After)
+	}
+}
@@ -1,31 +1,42 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/grading"
 	"github.com/school-system/backend/internal/models"
 	"gorm.io/gorm"
 )
 
+var ErrInvalidStream = errors.New("invalid stream")
+
 type SchoolSetupService struct {
-	db                    *gorm.DB
-	userAssignmentService *UserAssignmentService
+	db *gorm.DB
 }
 
 func NewSchoolSetupService(db *gorm.DB) *SchoolSetupService {
-	return &SchoolSetupService{
-		db:                    db,
-		userAssignmentService: NewUserAssignmentService(db),
-	}
+	return &SchoolSetupService{db: db}
 }
 
-// SetupSchool configures a school with classes, subjects, users, and default settings
-func (s *SchoolSetupService) SetupSchool(school *models.School, levels []string) error {
-	return s.db.Transaction(func(tx *gorm.DB) error {
+// InitialCredentials is a new account's one-time password. It is returned once,
+// when the account is created, and never stored.
+type InitialCredentials struct {
+	UserID          uuid.UUID `json:"user_id"`
+	Email           string    `json:"email"`
+	InitialPassword string    `json:"initial_password"`
+}
+
+// SetupSchool configures a school with classes, subjects, users, and default
+// settings, returning the default school admin's one-time credentials
+func (s *SchoolSetupService) SetupSchool(school *models.School, levels []string, hasher *PasswordHasher) (*InitialCredentials, error) {
+	var credentials *InitialCredentials
+	err := s.db.Transaction(func(tx *gorm.DB) error {
 		// 1. Create classes for each level
-		if err := s.createClasses(tx, school.ID, levels); err != nil {
+		if err := s.createClasses(tx, school.ID, levels, time.Now().Year()); err != nil {
 			return fmt.Errorf("failed to create classes: %w", err)
 		}
 
@@ -40,40 +51,155 @@ func (s *SchoolSetupService) SetupSchool(school *models.School, levels []string)
 		}
 
 		// 4. Create default school admin
-		if _, err := s.userAssignmentService.CreateSchoolAdmin(school.ID, school.Name); err != nil {
+		admin, password, err := NewUserAssignmentService(tx, hasher).CreateSchoolAdmin(school.ID, school.Name)
+		if err != nil {
 			return fmt.Errorf("failed to create school admin: %w", err)
 		}
+		credentials = &InitialCredentials{UserID: admin.ID, Email: admin.Email, InitialPassword: password}
 
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return credentials, nil
+}
+
+// CreateClassesForYear adds a class per level and stream for each term of an
+// academic year, such as the next year's classes at promotion time
+func (s *SchoolSetupService) CreateClassesForYear(schoolID uuid.UUID, levels []string, year int) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return s.createClasses(tx, schoolID, levels, year)
+	})
 }
 
-func (s *SchoolSetupService) createClasses(tx *gorm.DB, schoolID uuid.UUID, levels []string) error {
-	currentYear := time.Now().Year()
-	terms := []string{"Term1", "Term2", "Term3"}
+// AddStream adds a named stream to a level, creating its classes for each term
+// of the year. The teacher, if given, becomes the stream's class teacher.
+func (s *SchoolSetupService) AddStream(schoolID uuid.UUID, level, stream string, teacherID *uuid.UUID, year int) ([]models.Class, error) {
+	stream = strings.TrimSpace(stream)
+	if level == "" || stream == "" {
+		return nil, fmt.Errorf("%w: level and stream name are required", ErrInvalidStream)
+	}
 
-	for _, level := range levels {
-		for _, term := range terms {
-			// Check if class already exists
-			var existing models.Class
-			err := tx.Where("school_id = ? AND level = ? AND year = ? AND term = ?", schoolID, level, currentYear, term).First(&existing).Error
-			if err == nil {
-				// Class already exists, skip
-				continue
+	var classes []models.Class
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var school models.School
+		if err := tx.First(&school, "id = ?", schoolID).Error; err != nil {
+			return err
+		}
+
+		streams := LevelStreams(&school)
+		for _, existing := range streams[level] {
+			if strings.EqualFold(existing, stream) {
+				return fmt.Errorf("%w: %s %s already exists", ErrInvalidStream, level, existing)
 			}
-			if err != gorm.ErrRecordNotFound {
+		}
+		streams[level] = append(streams[level], stream)
+		if school.Config == nil {
+			school.Config = make(models.JSONB)
+		}
+		school.Config["streams"] = streams
+		if err := tx.Model(&school).Update("config", school.Config).Error; err != nil {
+			return err
+		}
+
+		if err := s.createClasses(tx, schoolID, []string{level}, year); err != nil {
+			return err
+		}
+		query := tx.Where("school_id = ? AND level = ? AND stream = ? AND year = ?", schoolID, level, stream, year)
+		if teacherID != nil {
+			if err := query.Session(&gorm.Session{}).Model(&models.Class{}).Update("teacher_id", teacherID).Error; err != nil {
 				return err
 			}
+		}
+		return query.Order("term").Find(&classes).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return classes, nil
+}
 
-			class := models.Class{
-				SchoolID: schoolID,
-				Name:     fmt.Sprintf("%s %s %d", level, term, currentYear),
-				Level:    level,
-				Year:     currentYear,
-				Term:     term,
+// LevelStreams reads the streams configured per level from the school's
+// "streams" config, e.g. {"P5": ["Blue", "Red"]}. Levels without streams have
+// a single unnamed class.
+func LevelStreams(school *models.School) map[string][]string {
+	streams := make(map[string][]string)
+	configured, _ := school.Config["streams"].(map[string]interface{})
+	for level, names := range configured {
+		list, _ := names.([]interface{})
+		for _, name := range list {
+			if stream, ok := name.(string); ok && stream != "" {
+				streams[level] = append(streams[level], stream)
 			}
-			if err := tx.Create(&class).Error; err != nil {
-				return err
+		}
+	}
+	return streams
+}
+
+// ClassName names a class, e.g. "P5 Term1 2026" or "P5 Blue Term1 2026"
+func ClassName(level, stream, term string, year int) string {
+	if stream == "" {
+		return fmt.Sprintf("%s %s %d", level, term, year)
+	}
+	return fmt.Sprintf("%s %s %s %d", level, stream, term, year)
+}
+
+// createClasses adds a class per level and stream for each term of the academic
+// year, defining the year with the default terms if the school has not. A
+// stream's class teacher carries over from its most recent class.
+func (s *SchoolSetupService) createClasses(tx *gorm.DB, schoolID uuid.UUID, levels []string, year int) error {
+	academicYear, err := NewAcademicCalendarService(tx).EnsureYear(schoolID, year)
+	if err != nil {
+		return err
+	}
+
+	var school models.School
+	if err := tx.First(&school, "id = ?", schoolID).Error; err != nil {
+		return err
+	}
+	streams := LevelStreams(&school)
+
+	for _, level := range levels {
+		levelStreams := streams[level]
+		if len(levelStreams) == 0 {
+			levelStreams = []string{""}
+		}
+		for _, stream := range levelStreams {
+			var teacherID *uuid.UUID
+			if stream != "" {
+				var previous models.Class
+				if err := tx.Where("school_id = ? AND level = ? AND stream = ? AND teacher_id IS NOT NULL", schoolID, level, stream).
+					Order("year DESC, created_at DESC").First(&previous).Error; err == nil {
+					teacherID = previous.TeacherID
+				}
+			}
+
+			for _, t := range academicYear.Terms {
+				term := t.Name
+				// Check if class already exists
+				var existing models.Class
+				err := tx.Where("school_id = ? AND level = ? AND stream = ? AND year = ? AND term = ?", schoolID, level, stream, year, term).First(&existing).Error
+				if err == nil {
+					// Class already exists, skip
+					continue
+				}
+				if err != gorm.ErrRecordNotFound {
+					return err
+				}
+
+				class := models.Class{
+					SchoolID:  schoolID,
+					Name:      ClassName(level, stream, term, year),
+					Level:     level,
+					Stream:    stream,
+					TeacherID: teacherID,
+					Year:      year,
+					Term:      term,
+				}
+				if err := tx.Create(&class).Error; err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -260,17 +386,8 @@ func (s *SchoolSetupService) createGradingRules(tx *gorm.DB, schoolID uuid.UUID,
 		var rules models.JSONB
 
 		if isPrimaryLevel(level) {
-			ruleVersion = "NCDC_PRIMARY_2023"
-			rules = models.JSONB{
-				"type": "primary",
-				"grades": map[string]interface{}{
-					"1": map[string]interface{}{"min": 80, "max": 100, "description": "Excellent"},
-					"2": map[string]interface{}{"min": 70, "max": 79, "description": "Very Good"},
-					"3": map[string]interface{}{"min": 60, "max": 69, "description": "Good"},
-					"4": map[string]interface{}{"min": 50, "max": 59, "description": "Satisfactory"},
-					"5": map[string]interface{}{"min": 0, "max": 49, "description": "Needs Improvement"},
-				},
-			}
+			ruleVersion = grading.DefaultPrimaryVersion
+			rules = grading.DefaultPrimaryRules()
 		} else if isSecondaryLevel(level) {
 			if level == "S5" || level == "S6" {
 				ruleVersion = "UNEB_ADVANCED_2023"
@@ -362,7 +479,7 @@ func isSecondaryLevel(level string) bool {
 func (s *SchoolSetupService) SetupNewLevels(schoolID uuid.UUID, newLevels []string) error {
 	return s.db.Transaction(func(tx *gorm.DB) error {
 		// Only create classes and subjects for new levels
-		if err := s.createClasses(tx, schoolID, newLevels); err != nil {
+		if err := s.createClasses(tx, schoolID, newLevels, time.Now().Year()); err != nil {
 			return fmt.Errorf("failed to create classes for new levels: %w", err)
 		}
 
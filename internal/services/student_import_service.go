@@ -0,0 +1,295 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// AdmissionNo builds a student's admission number, e.g. "NSS/S1/2025/004":
+// school initial, school type code, class level, year and a per-class sequence
+func AdmissionNo(school *models.School, level string, year, sequence int) string {
+	schoolInitial := string(school.Name[0])
+	var schoolType string
+	switch school.Type {
+	case "Nursery":
+		schoolType = "NS"
+	case "Primary":
+		schoolType = "PS"
+	default:
+		schoolType = "SS"
+	}
+	return fmt.Sprintf("%s%s/%s/%d/%03d", schoolInitial, schoolType, level, year, sequence)
+}
+
+// importColumns maps accepted header spellings to import fields
+var importColumns = map[string]string{
+	"first_name":       "first_name",
+	"firstname":        "first_name",
+	"last_name":        "last_name",
+	"lastname":         "last_name",
+	"surname":          "last_name",
+	"gender":           "gender",
+	"sex":              "gender",
+	"class_level":      "class_level",
+	"class":            "class_level",
+	"level":            "class_level",
+	"stream":           "stream",
+	"section":          "stream",
+	"term":             "term",
+	"year":             "year",
+	"admission_no":     "admission_no",
+	"admission_number": "admission_no",
+}
+
+var requiredImportColumns = []string{"first_name", "last_name", "class_level", "term", "year"}
+
+var headerCleaner = regexp.MustCompile(`[^a-z0-9]+`)
+
+// StudentImportRow is one data row of an import file and what it will create
+type StudentImportRow struct {
+	Row         int      `json:"row"`
+	FirstName   string   `json:"first_name"`
+	LastName    string   `json:"last_name"`
+	Gender      string   `json:"gender"`
+	ClassLevel  string   `json:"class_level"`
+	Stream      string   `json:"stream,omitempty"`
+	Term        string   `json:"term"`
+	Year        int      `json:"year"`
+	AdmissionNo string   `json:"admission_no"`
+	Errors      []string `json:"errors,omitempty"`
+
+	class *models.Class
+}
+
+// StudentImportReport summarises a dry run or committed import
+type StudentImportReport struct {
+	DryRun    bool               `json:"dry_run"`
+	Committed bool               `json:"committed"`
+	Total     int                `json:"total"`
+	Valid     int                `json:"valid"`
+	Invalid   int                `json:"invalid"`
+	Created   int                `json:"created"`
+	Rows      []StudentImportRow `json:"rows"`
+}
+
+type StudentImportService struct {
+	db              *gorm.DB
+	calendarService *AcademicCalendarService
+}
+
+func NewStudentImportService(db *gorm.DB) *StudentImportService {
+	return &StudentImportService{
+		db:              db,
+		calendarService: NewAcademicCalendarService(db),
+	}
+}
+
+// Import validates spreadsheet rows (header row first) and, unless dryRun is set
+// or any row is invalid, creates every Student and Enrollment in one transaction.
+// Rows without an admission number get the next free one in their class.
+func (s *StudentImportService) Import(school *models.School, rows [][]string, dryRun bool) (*StudentImportReport, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+
+	columns := make(map[string]int)
+	for i, header := range rows[0] {
+		key := strings.Trim(headerCleaner.ReplaceAllString(strings.ToLower(header), "_"), "_")
+		if field, ok := importColumns[key]; ok {
+			if _, seen := columns[field]; !seen {
+				columns[field] = i
+			}
+		}
+	}
+	var missing []string
+	for _, field := range requiredImportColumns {
+		if _, ok := columns[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required columns: %s", strings.Join(missing, ", "))
+	}
+
+	// Admission numbers already used in the school, plus those assigned in this file
+	var existing []string
+	if err := s.db.Model(&models.Student{}).Where("school_id = ?", school.ID).Pluck("admission_no", &existing).Error; err != nil {
+		return nil, err
+	}
+	taken := make(map[string]bool, len(existing))
+	for _, no := range existing {
+		taken[strings.ToUpper(no)] = true
+	}
+
+	report := &StudentImportReport{DryRun: dryRun}
+	classes := make(map[string]*models.Class)
+	var pending []*StudentImportRow
+
+	for i, cells := range rows[1:] {
+		if isBlankRow(cells) {
+			continue
+		}
+		cell := func(field string) string {
+			col, ok := columns[field]
+			if !ok || col >= len(cells) {
+				return ""
+			}
+			return cells[col]
+		}
+
+		row := StudentImportRow{
+			Row:         i + 2,
+			FirstName:   cell("first_name"),
+			LastName:    cell("last_name"),
+			ClassLevel:  cell("class_level"),
+			Stream:      cell("stream"),
+			Term:        cell("term"),
+			AdmissionNo: cell("admission_no"),
+		}
+		if row.FirstName == "" {
+			row.Errors = append(row.Errors, "first name is required")
+		}
+		if row.LastName == "" {
+			row.Errors = append(row.Errors, "last name is required")
+		}
+
+		switch strings.ToLower(cell("gender")) {
+		case "":
+		case "m", "male":
+			row.Gender = "Male"
+		case "f", "female":
+			row.Gender = "Female"
+		default:
+			row.Errors = append(row.Errors, fmt.Sprintf("gender %q must be Male or Female", cell("gender")))
+		}
+
+		year, err := strconv.Atoi(cell("year"))
+		if err != nil {
+			row.Errors = append(row.Errors, fmt.Sprintf("year %q is not a number", cell("year")))
+		}
+		row.Year = year
+
+		if row.ClassLevel == "" || row.Term == "" {
+			row.Errors = append(row.Errors, "class level and term are required")
+		} else if err == nil {
+			if err := s.calendarService.ValidateTerm(school.ID, row.Term, row.Year); err != nil {
+				row.Errors = append(row.Errors, err.Error())
+			}
+
+			key := fmt.Sprintf("%s|%s|%s|%d", row.ClassLevel, row.Stream, row.Term, row.Year)
+			class, ok := classes[key]
+			if !ok {
+				var c models.Class
+				if err := s.db.Where("school_id = ? AND level = ? AND stream = ? AND term = ? AND year = ?", school.ID, row.ClassLevel, row.Stream, row.Term, row.Year).
+					First(&c).Error; err == nil {
+					class = &c
+				}
+				classes[key] = class
+			}
+			if class == nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("no %s class for %s %d", strings.TrimSpace(row.ClassLevel+" "+row.Stream), row.Term, row.Year))
+			}
+			row.class = class
+		}
+
+		if row.AdmissionNo != "" {
+			if taken[strings.ToUpper(row.AdmissionNo)] {
+				row.Errors = append(row.Errors, fmt.Sprintf("admission number %s is already in use", row.AdmissionNo))
+			} else {
+				taken[strings.ToUpper(row.AdmissionNo)] = true
+			}
+		}
+
+		report.Rows = append(report.Rows, row)
+	}
+
+	for i := range report.Rows {
+		row := &report.Rows[i]
+		if len(row.Errors) > 0 {
+			report.Invalid++
+			continue
+		}
+		report.Valid++
+		pending = append(pending, row)
+	}
+	report.Total = len(report.Rows)
+
+	// Assign generated admission numbers once explicit ones have all been reserved
+	sequences := make(map[*models.Class]int)
+	for _, row := range pending {
+		if row.AdmissionNo != "" {
+			continue
+		}
+		sequence, ok := sequences[row.class]
+		if !ok {
+			var count int64
+			s.db.Table("students").Joins("JOIN enrollments ON students.id = enrollments.student_id").
+				Where("enrollments.class_id = ?", row.class.ID).Count(&count)
+			sequence = int(count)
+		}
+		for {
+			sequence++
+			no := AdmissionNo(school, row.class.Level, row.Year, sequence)
+			if !taken[strings.ToUpper(no)] {
+				taken[strings.ToUpper(no)] = true
+				row.AdmissionNo = no
+				break
+			}
+		}
+		sequences[row.class] = sequence
+	}
+
+	if dryRun || report.Invalid > 0 || len(pending) == 0 {
+		return report, nil
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		for _, row := range pending {
+			student := models.Student{
+				SchoolID:    school.ID,
+				AdmissionNo: row.AdmissionNo,
+				FirstName:   row.FirstName,
+				LastName:    row.LastName,
+				Gender:      row.Gender,
+			}
+			if err := tx.Create(&student).Error; err != nil {
+				return fmt.Errorf("row %d: %w", row.Row, err)
+			}
+
+			enrollment := models.Enrollment{
+				StudentID:  student.ID,
+				ClassID:    row.class.ID,
+				Year:       row.Year,
+				Term:       row.Term,
+				Status:     "active",
+				EnrolledOn: time.Now(),
+			}
+			if err := tx.Create(&enrollment).Error; err != nil {
+				return fmt.Errorf("row %d: %w", row.Row, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("import failed, no students were created: %w", err)
+	}
+
+	report.Committed = true
+	report.Created = len(pending)
+	return report, nil
+}
+
+func isBlankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotAssigned       = errors.New("you are not assigned to teach this subject in this class")
+	ErrInvalidAssignment = errors.New("invalid teacher assignment")
+)
+
+// markWriterRoles may enter marks and results for any subject in their school
+// without an assignment
+var markWriterRoles = []string{"system_admin", "school_admin", "head_of_department"}
+
+type TeacherAssignmentService struct {
+	db *gorm.DB
+}
+
+func NewTeacherAssignmentService(db *gorm.DB) *TeacherAssignmentService {
+	return &TeacherAssignmentService{db: db}
+}
+
+// Assign lets a teacher enter marks for a subject in a class. The user must be
+// an active teacher, and the teacher, class and subject must belong together:
+// same school, subject offered at the class's level.
+func (s *TeacherAssignmentService) Assign(teacherID, classID, subjectID uuid.UUID) (*models.TeacherSubjectAssignment, error) {
+	var teacher models.User
+	if err := s.db.First(&teacher, "id = ?", teacherID).Error; err != nil {
+		return nil, fmt.Errorf("%w: teacher not found", ErrInvalidAssignment)
+	}
+	if teacher.Role != "teacher" {
+		return nil, fmt.Errorf("%w: %s is not a teacher", ErrInvalidAssignment, teacher.FullName)
+	}
+	if !teacher.IsActive {
+		return nil, fmt.Errorf("%w: %s is deactivated", ErrInvalidAssignment, teacher.FullName)
+	}
+	var class models.Class
+	if err := s.db.First(&class, "id = ?", classID).Error; err != nil {
+		return nil, fmt.Errorf("%w: class not found", ErrInvalidAssignment)
+	}
+	if teacher.SchoolID == nil || *teacher.SchoolID != class.SchoolID {
+		return nil, fmt.Errorf("%w: teacher and class must belong to the same school", ErrInvalidAssignment)
+	}
+	var subject models.StandardSubject
+	if err := s.db.First(&subject, "id = ?", subjectID).Error; err != nil {
+		return nil, fmt.Errorf("%w: subject not found", ErrInvalidAssignment)
+	}
+	if subject.Level != class.Level {
+		return nil, fmt.Errorf("%w: %s is not offered at %s", ErrInvalidAssignment, subject.Name, class.Level)
+	}
+
+	assignment := models.TeacherSubjectAssignment{
+		SchoolID:  class.SchoolID,
+		TeacherID: teacherID,
+		ClassID:   classID,
+		SubjectID: subjectID,
+		Term:      class.Term,
+		Year:      class.Year,
+	}
+	err := s.db.Where("teacher_id = ? AND class_id = ? AND subject_id = ?", teacherID, classID, subjectID).
+		FirstOrCreate(&assignment).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign teacher: %w", err)
+	}
+	return &assignment, nil
+}
+
+// ForTeacher lists a teacher's assignments with their classes and subjects,
+// newest term first
+func (s *TeacherAssignmentService) ForTeacher(teacherID uuid.UUID, term string, year int) ([]models.TeacherSubjectAssignment, error) {
+	query := s.db.Preload("Class").Preload("StandardSubject").Where("teacher_id = ?", teacherID)
+	if term != "" {
+		query = query.Where("term = ?", term)
+	}
+	if year > 0 {
+		query = query.Where("year = ?", year)
+	}
+
+	var assignments []models.TeacherSubjectAssignment
+	if err := query.Order("year DESC, term DESC").Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// CanWrite reports whether a user may enter marks and results for a subject in
+// a class. Admins and heads of department may write for any subject in their
+// school; teachers need an assignment, and any other role is refused.
+func (s *TeacherAssignmentService) CanWrite(userID uuid.UUID, role string, classID, subjectID uuid.UUID) (bool, error) {
+	if containsString(markWriterRoles, role) {
+		return true, nil
+	}
+	if role != "teacher" {
+		return false, nil
+	}
+
+	var count int64
+	if err := s.db.Model(&models.TeacherSubjectAssignment{}).
+		Where("teacher_id = ? AND class_id = ? AND subject_id = ?", userID, classID, subjectID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
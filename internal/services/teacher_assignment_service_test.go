@@ -0,0 +1,120 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type assignmentFixture struct {
+	db      *gorm.DB
+	school  uuid.UUID
+	class   models.Class
+	subject models.StandardSubject
+}
+
+func newAssignmentFixture(t *testing.T) *assignmentFixture {
+	t.Helper()
+	db := newTestDB(t, &models.User{}, &models.Class{}, &models.StandardSubject{}, &models.TeacherSubjectAssignment{})
+	f := &assignmentFixture{db: db, school: uuid.New()}
+	f.class = models.Class{SchoolID: f.school, Name: "S2 East", Level: "S2", Stream: "East", Term: "Term1", Year: 2025}
+	mustCreate(t, db, &f.class)
+	f.subject = models.StandardSubject{Name: "Mathematics", Code: "MTH", Level: "S2"}
+	mustCreate(t, db, &f.subject)
+	return f
+}
+
+func (f *assignmentFixture) user(t *testing.T, role string, active bool, schoolID uuid.UUID) models.User {
+	t.Helper()
+	user := models.User{SchoolID: &schoolID, Email: uuid.NewString() + "@school.ug", PasswordHash: "hash", Role: role, FullName: "Staff " + role, IsActive: true}
+	mustCreate(t, f.db, &user)
+	if !active {
+		// is_active defaults to true, so a false value is not inserted
+		if err := f.db.Model(&user).Update("is_active", false).Error; err != nil {
+			t.Fatalf("Failed to deactivate: %v", err)
+		}
+	}
+	return user
+}
+
+func TestTeacherAssignment_Assign(t *testing.T) {
+	f := newAssignmentFixture(t)
+	service := NewTeacherAssignmentService(f.db)
+	teacher := f.user(t, "teacher", true, f.school)
+
+	assignment, err := service.Assign(teacher.ID, f.class.ID, f.subject.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if assignment.Term != "Term1" || assignment.Year != 2025 || assignment.SchoolID != f.school {
+		t.Errorf("Expected the assignment to take the class's school and term, got %+v", assignment)
+	}
+	again, err := service.Assign(teacher.ID, f.class.ID, f.subject.ID)
+	if err != nil || again.ID != assignment.ID {
+		t.Errorf("Expected assigning twice to return the same assignment, got %v (%v)", again, err)
+	}
+
+	otherLevel := models.StandardSubject{Name: "Chemistry", Code: "CHE", Level: "S5"}
+	mustCreate(t, f.db, &otherLevel)
+
+	tests := []struct {
+		name      string
+		teacherID uuid.UUID
+		subjectID uuid.UUID
+	}{
+		{"deactivated teacher", f.user(t, "teacher", false, f.school).ID, f.subject.ID},
+		{"school admin", f.user(t, "school_admin", true, f.school).ID, f.subject.ID},
+		{"head of department", f.user(t, "head_of_department", true, f.school).ID, f.subject.ID},
+		{"teacher from another school", f.user(t, "teacher", true, uuid.New()).ID, f.subject.ID},
+		{"unknown teacher", uuid.New(), f.subject.ID},
+		{"subject from another level", teacher.ID, otherLevel.ID},
+	}
+	for _, tt := range tests {
+		if _, err := service.Assign(tt.teacherID, f.class.ID, tt.subjectID); !errors.Is(err, ErrInvalidAssignment) {
+			t.Errorf("%s: expected ErrInvalidAssignment, got %v", tt.name, err)
+		}
+	}
+}
+
+func TestTeacherAssignment_CanWrite(t *testing.T) {
+	f := newAssignmentFixture(t)
+	service := NewTeacherAssignmentService(f.db)
+	assigned := f.user(t, "teacher", true, f.school)
+	if _, err := service.Assign(assigned.ID, f.class.ID, f.subject.ID); err != nil {
+		t.Fatalf("Failed to assign: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		userID   uuid.UUID
+		role     string
+		expected bool
+	}{
+		{"assigned teacher", assigned.ID, "teacher", true},
+		{"unassigned teacher", uuid.New(), "teacher", false},
+		{"school admin", uuid.New(), "school_admin", true},
+		{"head of department", uuid.New(), "head_of_department", true},
+		{"system admin", uuid.New(), "system_admin", true},
+		{"unknown role", assigned.ID, "parent", false},
+		{"no role", assigned.ID, "", false},
+	}
+	for _, tt := range tests {
+		allowed, err := service.CanWrite(tt.userID, tt.role, f.class.ID, f.subject.ID)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+		if allowed != tt.expected {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.expected, allowed)
+		}
+	}
+
+	// An assignment covers one subject only
+	other := models.StandardSubject{Name: "English", Code: "ENG", Level: "S2"}
+	mustCreate(t, f.db, &other)
+	if allowed, _ := service.CanWrite(assigned.ID, "teacher", f.class.ID, other.ID); allowed {
+		t.Error("Expected the teacher refused for a subject they are not assigned")
+	}
+}
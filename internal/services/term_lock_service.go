@@ -0,0 +1,113 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTermLocked            = errors.New("term is locked")
+	ErrJustificationRequired = errors.New("a justification is required to unlock a term")
+)
+
+type TermLockService struct {
+	db *gorm.DB
+}
+
+func NewTermLockService(db *gorm.DB) *TermLockService {
+	return &TermLockService{db: db}
+}
+
+// Find returns the active lock for a school's term, or nil if it is open
+func (s *TermLockService) Find(schoolID uuid.UUID, term string, year int) (*models.TermLock, error) {
+	var lock models.TermLock
+	err := s.db.Where("school_id = ? AND term = ? AND year = ?", schoolID, term, year).First(&lock).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &lock, nil
+}
+
+// EnsureUnlocked returns an error wrapping ErrTermLocked if writes to the term are frozen
+func (s *TermLockService) EnsureUnlocked(schoolID uuid.UUID, term string, year int) error {
+	lock, err := s.Find(schoolID, term, year)
+	if err != nil {
+		return err
+	}
+	if lock != nil {
+		return fmt.Errorf("%w: %s %d was locked on %s - marks and results can no longer be changed",
+			ErrTermLocked, term, year, lock.LockedAt.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// LockedTerms returns the locked terms of the given schools, keyed by TermKey
+func (s *TermLockService) LockedTerms(schoolIDs []uuid.UUID) (map[string]bool, error) {
+	var locks []models.TermLock
+	if err := s.db.Where("school_id IN ?", schoolIDs).Find(&locks).Error; err != nil {
+		return nil, err
+	}
+	locked := make(map[string]bool, len(locks))
+	for _, lock := range locks {
+		locked[TermKey(lock.SchoolID, lock.Term, lock.Year)] = true
+	}
+	return locked, nil
+}
+
+// TermKey identifies a school's term in the map returned by LockedTerms
+func TermKey(schoolID uuid.UUID, term string, year int) string {
+	return fmt.Sprintf("%s|%s|%d", schoolID, term, year)
+}
+
+// Lock freezes a school's term; locking an already locked term returns the
+// existing lock. The audit callbacks record the new lock.
+func (s *TermLockService) Lock(schoolID uuid.UUID, term string, year int, reason string, actorID uuid.UUID) (*models.TermLock, error) {
+	existing, err := s.Find(schoolID, term, year)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	lock := models.TermLock{
+		SchoolID: schoolID,
+		Term:     term,
+		Year:     year,
+		LockedBy: actorID,
+		LockedAt: time.Now(),
+		Reason:   reason,
+	}
+	// idx_term_lock is unique, so a concurrent request that locked the term
+	// first leaves nothing to insert
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&lock)
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to lock term: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return s.Find(schoolID, term, year)
+	}
+	return &lock, nil
+}
+
+// Unlock reopens a locked term. The justification is written to the audit log
+// in a TERM_UNLOCK entry, which stands in for the callbacks' DELETE.
+func (s *TermLockService) Unlock(lock *models.TermLock, justification string, actorID uuid.UUID, ip string) error {
+	if justification == "" {
+		return ErrJustificationRequired
+	}
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Set(models.SkipAudit, true).Delete(lock).Error; err != nil {
+			return err
+		}
+		return NewAuditService(tx).Log(actorID, "TERM_UNLOCK", "term_lock", lock.ID,
+			models.JSONB{"term": lock.Term, "year": lock.Year, "locked_at": lock.LockedAt},
+			models.JSONB{"justification": justification}, ip)
+	})
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/database"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// newAuditedTestDB is newTestDB with the audit callbacks registered
+func newAuditedTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db := newTestDB(t, append(tables, &models.User{}, &models.AuditLog{})...)
+	if err := database.RegisterAuditCallbacks(db); err != nil {
+		t.Fatalf("Failed to register audit callbacks: %v", err)
+	}
+	return db
+}
+
+func countAuditLogs(t *testing.T, db *gorm.DB, resourceID uuid.UUID) map[string]int {
+	t.Helper()
+	var logs []models.AuditLog
+	if err := db.Where("resource_id = ?", resourceID).Find(&logs).Error; err != nil {
+		t.Fatalf("Failed to load audit logs: %v", err)
+	}
+	actions := make(map[string]int)
+	for _, log := range logs {
+		actions[log.Action]++
+	}
+	return actions
+}
+
+func TestTermLock_LockAndUnlock(t *testing.T) {
+	db := newAuditedTestDB(t, &models.TermLock{})
+	service := NewTermLockService(db)
+	schoolID, actorID := uuid.New(), uuid.New()
+
+	if err := service.EnsureUnlocked(schoolID, "Term1", 2025); err != nil {
+		t.Fatalf("Expected an open term, got %v", err)
+	}
+
+	lock, err := service.Lock(schoolID, "Term1", 2025, "Report cards issued", actorID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if lock.LockedBy != actorID || lock.Reason != "Report cards issued" {
+		t.Errorf("Expected the lock to record who locked it and why, got %+v", lock)
+	}
+
+	err = service.EnsureUnlocked(schoolID, "Term1", 2025)
+	if !errors.Is(err, ErrTermLocked) {
+		t.Fatalf("Expected ErrTermLocked, got %v", err)
+	}
+	// Other terms and schools stay open
+	if err := service.EnsureUnlocked(schoolID, "Term2", 2025); err != nil {
+		t.Errorf("Expected Term2 open, got %v", err)
+	}
+	if err := service.EnsureUnlocked(uuid.New(), "Term1", 2025); err != nil {
+		t.Errorf("Expected another school's term open, got %v", err)
+	}
+
+	// Locking again returns the existing lock
+	again, err := service.Lock(schoolID, "Term1", 2025, "Again", uuid.New())
+	if err != nil || again.ID != lock.ID {
+		t.Errorf("Expected the existing lock %s, got %v (%v)", lock.ID, again, err)
+	}
+
+	if err := service.Unlock(lock, "", actorID, "10.0.0.1"); !errors.Is(err, ErrJustificationRequired) {
+		t.Errorf("Expected ErrJustificationRequired, got %v", err)
+	}
+	if err := service.Unlock(lock, "Marks entered against the wrong student", actorID, "10.0.0.1"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := service.EnsureUnlocked(schoolID, "Term1", 2025); err != nil {
+		t.Errorf("Expected the term open after unlocking, got %v", err)
+	}
+
+	// One entry per change: the callbacks' CREATE and the justified unlock
+	actions := countAuditLogs(t, db, lock.ID)
+	if len(actions) != 2 || actions["CREATE"] != 1 || actions["TERM_UNLOCK"] != 1 {
+		t.Errorf("Expected one CREATE and one TERM_UNLOCK entry, got %v", actions)
+	}
+
+	// The term can be locked again once reopened
+	relocked, err := service.Lock(schoolID, "Term1", 2025, "Corrected", actorID)
+	if err != nil || relocked.ID == lock.ID {
+		t.Errorf("Expected a new lock, got %v (%v)", relocked, err)
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/grading"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type TermSummaryService struct {
+	db          *gorm.DB
+	ruleService *GradingRuleService
+}
+
+func NewTermSummaryService(db *gorm.DB) *TermSummaryService {
+	return &TermSummaryService{
+		db:          db,
+		ruleService: NewGradingRuleService(db),
+	}
+}
+
+// ComputeForStudent aggregates a student's subject results for the class's term and
+// stores them on the student's TermSummary. P7 gets a PLE aggregate and division,
+// S5/S6 get UACE points; other levels get an average score only. Results in
+// every workflow status count, so the summary tracks marks as they are entered.
+func (s *TermSummaryService) ComputeForStudent(studentID uuid.UUID, class *models.Class) (*models.TermSummary, error) {
+	var summary models.TermSummary
+	err := s.db.Where("student_id = ? AND term = ? AND year = ?", studentID, class.Term, class.Year).First(&summary).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err := s.summarize(&summary, studentID, class, false); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(&summary).Error; err != nil {
+		return nil, fmt.Errorf("failed to save term summary: %w", err)
+	}
+
+	return &summary, nil
+}
+
+// PublishedSummary works out a student's term summary like ComputeForStudent
+// but from published results only, as printed on report cards. It is not stored.
+func (s *TermSummaryService) PublishedSummary(studentID uuid.UUID, class *models.Class) (*models.TermSummary, error) {
+	summary := &models.TermSummary{}
+	if err := s.summarize(summary, studentID, class, true); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// summarize fills in a summary's figures from the student's results for the
+// class's term
+func (s *TermSummaryService) summarize(summary *models.TermSummary, studentID uuid.UUID, class *models.Class, publishedOnly bool) error {
+	type resultRow struct {
+		FinalGrade   string
+		DerivedCodes models.JSONB
+		Code         string
+		Level        string
+		Papers       int
+	}
+
+	var rows []resultRow
+	query := s.db.Table("subject_results").
+		Select("subject_results.final_grade, subject_results.derived_codes, standard_subjects.code, standard_subjects.level, standard_subjects.papers").
+		Joins("JOIN standard_subjects ON subject_results.subject_id = standard_subjects.id").
+		Where("subject_results.student_id = ? AND subject_results.term = ? AND subject_results.year = ? AND subject_results.deleted_at IS NULL",
+			studentID, class.Term, class.Year)
+	if publishedOnly {
+		query = query.Where("subject_results.status = ?", ResultStatusPublished)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	scores := make([]grading.SubjectScore, 0, len(rows))
+	sum := 0.0
+	for _, row := range rows {
+		total := 0.0
+		if row.DerivedCodes != nil {
+			if t, ok := row.DerivedCodes["total"].(float64); ok {
+				total = t
+			}
+		}
+		sum += total
+		scores = append(scores, grading.SubjectScore{
+			Code:       row.Code,
+			Grade:      row.FinalGrade,
+			Total:      total,
+			Subsidiary: isSubsidiary(&models.StandardSubject{Level: row.Level, Papers: row.Papers}),
+		})
+	}
+
+	summary.StudentID = studentID
+	summary.ClassID = class.ID
+	summary.SchoolID = class.SchoolID
+	summary.Term = class.Term
+	summary.Year = class.Year
+	summary.Level = class.Level
+	summary.SubjectCount = len(scores)
+	summary.AverageScore = 0
+	if len(scores) > 0 {
+		summary.AverageScore = sum / float64(len(scores))
+	}
+	summary.Aggregate = nil
+	summary.Division = ""
+	summary.Points = nil
+	summary.ComputationReason = fmt.Sprintf("Average of %d subject(s): %.2f", len(scores), summary.AverageScore)
+
+	var aggregate grading.AggregateResult
+	var aggErr error
+	switch class.Level {
+	case "P7":
+		aggregate, aggErr = grading.ComputePLEAggregate(scores)
+	case "S5", "S6":
+		rules, err := s.ruleService.LoadRuleSet(class.SchoolID, class.Level)
+		if err != nil {
+			return err
+		}
+		var pointsFor func(string) (int, bool)
+		if rules != nil {
+			pointsFor = rules.Points
+		}
+		aggregate, aggErr = grading.ComputeUACEPoints(scores, pointsFor)
+	}
+
+	if aggErr != nil {
+		summary.ComputationReason += fmt.Sprintf("; aggregate not computed: %v", aggErr)
+	} else if aggregate.Reason != "" {
+		summary.Aggregate = aggregate.Aggregate
+		summary.Division = aggregate.Division
+		summary.Points = aggregate.Points
+		summary.ComputationReason += "; " + aggregate.Reason
+	}
+	return nil
+}
+
+// GetForStudent returns a student's stored summary for a term, or nil if none exists
+func (s *TermSummaryService) GetForStudent(studentID uuid.UUID, term string, year int) (*models.TermSummary, error) {
+	var summary models.TermSummary
+	err := s.db.Where("student_id = ? AND term = ? AND year = ?", studentID, term, year).First(&summary).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
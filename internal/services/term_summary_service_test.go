@@ -0,0 +1,59 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+)
+
+func TestTermSummary_PublishedOnly(t *testing.T) {
+	db := newTestDB(t, &models.StandardSubject{}, &models.SubjectResult{}, &models.TermSummary{})
+	service := NewTermSummaryService(db)
+
+	class := models.Class{BaseModel: models.BaseModel{ID: uuid.New()}, SchoolID: uuid.New(), Level: "S2", Term: "Term1", Year: 2025}
+	studentID := uuid.New()
+	for _, result := range []struct {
+		code   string
+		total  float64
+		status string
+	}{
+		{"MTH", 80, ResultStatusPublished},
+		{"ENG", 60, ResultStatusPublished},
+		{"BIO", 10, ResultStatusDraft},
+	} {
+		subject := models.StandardSubject{Name: result.code, Code: result.code, Level: class.Level}
+		mustCreate(t, db, &subject)
+		mustCreate(t, db, &models.SubjectResult{
+			StudentID:    studentID,
+			SubjectID:    subject.ID,
+			ClassID:      class.ID,
+			SchoolID:     class.SchoolID,
+			Term:         class.Term,
+			Year:         class.Year,
+			DerivedCodes: models.JSONB{"total": result.total},
+			Status:       result.status,
+		})
+	}
+
+	// The stored summary follows every result, drafts included
+	stored, err := service.ComputeForStudent(studentID, &class)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stored.SubjectCount != 3 || stored.AverageScore != 50 {
+		t.Errorf("Expected 3 subjects averaging 50, got %d averaging %.2f", stored.SubjectCount, stored.AverageScore)
+	}
+
+	// The report card's summary leaves the draft out and is not stored
+	published, err := service.PublishedSummary(studentID, &class)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if published.SubjectCount != 2 || published.AverageScore != 70 {
+		t.Errorf("Expected 2 published subjects averaging 70, got %d averaging %.2f", published.SubjectCount, published.AverageScore)
+	}
+	if again, _ := service.GetForStudent(studentID, class.Term, class.Year); again == nil || again.SubjectCount != 3 {
+		t.Errorf("Expected the stored summary left as computed, got %+v", again)
+	}
+}
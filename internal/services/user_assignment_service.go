@@ -1,51 +1,55 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/school-system/backend/internal/models"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrUserNotInSchool = errors.New("user not found in this school")
+	ErrInvalidRole     = errors.New("invalid role")
+)
+
+// SchoolRoles are the roles a school may give its own staff; system_admin is
+// never one of them
+var SchoolRoles = []string{"school_admin", "head_of_department", "teacher"}
+
 type UserAssignmentService struct {
-	db *gorm.DB
+	db     *gorm.DB
+	hasher *PasswordHasher
 }
 
-func NewUserAssignmentService(db *gorm.DB) *UserAssignmentService {
-	return &UserAssignmentService{db: db}
+func NewUserAssignmentService(db *gorm.DB, hasher *PasswordHasher) *UserAssignmentService {
+	return &UserAssignmentService{db: db, hasher: hasher}
 }
 
-// CreateSchoolAdmin creates a default admin user for a school
-func (s *UserAssignmentService) CreateSchoolAdmin(schoolID uuid.UUID, schoolName string) (*models.User, error) {
-	// Generate default admin credentials
-	email := fmt.Sprintf("admin@%s.ug", generateSlug(schoolName))
-	password := "Admin@123"
-	
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// CreateSchoolAdmin creates a default admin user for a school. The returned
+// one-time password is not stored; the admin must replace it at first login.
+func (s *UserAssignmentService) CreateSchoolAdmin(schoolID uuid.UUID, schoolName string) (*models.User, string, error) {
+	password, hash, err := s.initialPassword()
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+		return nil, "", err
 	}
 
 	admin := &models.User{
-		SchoolID:     &schoolID,
-		Email:        email,
-		PasswordHash: string(hashedPassword),
-		Role:         "school_admin",
-		FullName:     fmt.Sprintf("%s Administrator", schoolName),
-		IsActive:     true,
-		Meta: models.JSONB{
-			"default_password": password,
-			"must_change_password": true,
-		},
+		SchoolID:           &schoolID,
+		Email:              fmt.Sprintf("admin@%s.ug", generateSlug(schoolName)),
+		PasswordHash:       hash,
+		Role:               "school_admin",
+		FullName:           fmt.Sprintf("%s Administrator", schoolName),
+		IsActive:           true,
+		MustChangePassword: true,
 	}
 
 	if err := s.db.Create(admin).Error; err != nil {
-		return nil, fmt.Errorf("failed to create admin user: %w", err)
+		return nil, "", fmt.Errorf("failed to create admin user: %w", err)
 	}
 
-	return admin, nil
+	return admin, password, nil
 }
 
 // AssignTeacherToClass assigns a teacher to a specific class
@@ -75,64 +79,132 @@ func (s *UserAssignmentService) AssignTeacherToClass(teacherID, classID uuid.UUI
 	return nil
 }
 
-// CreateTeacher creates a new teacher for a school
-func (s *UserAssignmentService) CreateTeacher(schoolID uuid.UUID, fullName, email string) (*models.User, error) {
-	password := "Teacher@123"
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// CreateTeacher creates a new teacher for a school with a one-time password,
+// returned but not stored, that must be changed at first login
+func (s *UserAssignmentService) CreateTeacher(schoolID uuid.UUID, fullName, email string) (*models.User, string, error) {
+	password, hash, err := s.initialPassword()
 	if err != nil {
-		return nil, fmt.Errorf("failed to hash password: %w", err)
+		return nil, "", err
 	}
 
 	teacher := &models.User{
-		SchoolID:     &schoolID,
-		Email:        email,
-		PasswordHash: string(hashedPassword),
-		Role:         "teacher",
-		FullName:     fullName,
-		IsActive:     true,
-		Meta: models.JSONB{
-			"default_password": password,
-			"must_change_password": true,
-		},
+		SchoolID:           &schoolID,
+		Email:              email,
+		PasswordHash:       hash,
+		Role:               "teacher",
+		FullName:           fullName,
+		IsActive:           true,
+		MustChangePassword: true,
 	}
 
 	if err := s.db.Create(teacher).Error; err != nil {
-		return nil, fmt.Errorf("failed to create teacher: %w", err)
+		return nil, "", fmt.Errorf("failed to create teacher: %w", err)
 	}
 
-	return teacher, nil
+	return teacher, password, nil
 }
 
-// GetSchoolUsers returns all users for a specific school
-func (s *UserAssignmentService) GetSchoolUsers(schoolID uuid.UUID) ([]models.User, error) {
+// GetSchoolUsers returns a school's users, optionally only those with a role
+// or active state
+func (s *UserAssignmentService) GetSchoolUsers(schoolID uuid.UUID, role string, active *bool) ([]models.User, error) {
 	var users []models.User
-	if err := s.db.Where("school_id = ?", schoolID).Find(&users).Error; err != nil {
+	query := s.db.Where("school_id = ?", schoolID)
+	if role != "" {
+		query = query.Where("role = ?", role)
+	}
+	if active != nil {
+		query = query.Where("is_active = ?", *active)
+	}
+	if err := query.Order("full_name").Find(&users).Error; err != nil {
 		return nil, fmt.Errorf("failed to get school users: %w", err)
 	}
 	return users, nil
 }
 
 // UpdateUserRole updates a user's role within the school
-func (s *UserAssignmentService) UpdateUserRole(userID uuid.UUID, newRole string) error {
-	validRoles := []string{"school_admin", "teacher"}
-	isValid := false
-	for _, role := range validRoles {
-		if role == newRole {
-			isValid = true
-			break
-		}
-	}
-	if !isValid {
-		return fmt.Errorf("invalid role: %s", newRole)
+func (s *UserAssignmentService) UpdateUserRole(schoolID, userID uuid.UUID, newRole string) error {
+	if !containsString(SchoolRoles, newRole) {
+		return fmt.Errorf("%w: %s", ErrInvalidRole, newRole)
 	}
 
-	if err := s.db.Model(&models.User{}).Where("id = ?", userID).Update("role", newRole).Error; err != nil {
-		return fmt.Errorf("failed to update user role: %w", err)
+	// System admins are out of reach of school-level management
+	result := s.db.Model(&models.User{}).Where("id = ? AND school_id = ? AND role <> ?", userID, schoolID, "system_admin").
+		Update("role", newRole)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update user role: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotInSchool
 	}
 
 	return nil
 }
 
+// SetUserActive deactivates or reactivates a school's user. Deactivation
+// revokes the user's refresh tokens so they are signed out once their access
+// token expires.
+func (s *UserAssignmentService) SetUserActive(schoolID, userID uuid.UUID, active bool) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ? AND school_id = ? AND role <> ?", userID, schoolID, "system_admin").
+			Update("is_active", active)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserNotInSchool
+		}
+		if active {
+			return nil
+		}
+		return tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked = ?", userID, false).
+			Update("revoked", true).Error
+	})
+}
+
+// ResetPassword gives one of the school's users a new one-time password,
+// returned but not stored, that must be changed at next login. The user's
+// refresh tokens are revoked so existing sessions end.
+func (s *UserAssignmentService) ResetPassword(schoolID, userID uuid.UUID) (string, error) {
+	password, hash, err := s.initialPassword()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.User{}).Where("id = ? AND school_id = ? AND role <> ?", userID, schoolID, "system_admin").
+			Updates(map[string]interface{}{
+				"password_hash":        hash,
+				"must_change_password": true,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserNotInSchool
+		}
+		return tx.Model(&models.RefreshToken{}).Where("user_id = ? AND revoked = ?", userID, false).
+			Update("revoked", true).Error
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return password, nil
+}
+
+// initialPassword generates a one-time password and its hash
+func (s *UserAssignmentService) initialPassword() (string, string, error) {
+	password, err := GenerateInitialPassword()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	hash, err := s.hasher.Hash(password)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return password, hash, nil
+}
+
 func generateSlug(name string) string {
 	// Simple slug generation - replace spaces with hyphens and convert to lowercase
 	slug := ""
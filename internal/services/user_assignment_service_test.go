@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/school-system/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+func createStaff(t *testing.T, db *gorm.DB, schoolID *uuid.UUID, role string) models.User {
+	t.Helper()
+	user := models.User{SchoolID: schoolID, Email: uuid.NewString() + "@school.ug", PasswordHash: "hash", Role: role, FullName: "Staff " + role, IsActive: true}
+	mustCreate(t, db, &user)
+	return user
+}
+
+func createRefreshToken(t *testing.T, db *gorm.DB, userID uuid.UUID) models.RefreshToken {
+	t.Helper()
+	token := models.RefreshToken{UserID: userID, Token: uuid.NewString(), ExpiresAt: time.Now().Add(time.Hour)}
+	mustCreate(t, db, &token)
+	return token
+}
+
+func revoked(t *testing.T, db *gorm.DB, token models.RefreshToken) bool {
+	t.Helper()
+	var stored models.RefreshToken
+	if err := db.First(&stored, "id = ?", token.ID).Error; err != nil {
+		t.Fatalf("Failed to load refresh token: %v", err)
+	}
+	return stored.Revoked
+}
+
+func TestUpdateUserRole(t *testing.T) {
+	db := newTestDB(t, &models.User{})
+	service := NewUserAssignmentService(db, NewPasswordHasher(testArgon2))
+	schoolID := uuid.New()
+	teacher := createStaff(t, db, &schoolID, "teacher")
+
+	if err := service.UpdateUserRole(schoolID, teacher.ID, "head_of_department"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var stored models.User
+	db.First(&stored, "id = ?", teacher.ID)
+	if stored.Role != "head_of_department" {
+		t.Errorf("Expected head_of_department, got %s", stored.Role)
+	}
+
+	for _, role := range []string{"system_admin", "parent", ""} {
+		if err := service.UpdateUserRole(schoolID, teacher.ID, role); !errors.Is(err, ErrInvalidRole) {
+			t.Errorf("%q: expected ErrInvalidRole, got %v", role, err)
+		}
+	}
+
+	// System admins and other schools' staff are out of reach
+	systemAdmin := createStaff(t, db, &schoolID, "system_admin")
+	otherSchool := uuid.New()
+	outsider := createStaff(t, db, &otherSchool, "teacher")
+	for _, user := range []models.User{systemAdmin, outsider} {
+		if err := service.UpdateUserRole(schoolID, user.ID, "school_admin"); !errors.Is(err, ErrUserNotInSchool) {
+			t.Errorf("%s: expected ErrUserNotInSchool, got %v", user.Role, err)
+		}
+	}
+	var admin models.User
+	db.First(&admin, "id = ?", systemAdmin.ID)
+	if admin.Role != "system_admin" {
+		t.Errorf("Expected the system admin left alone, got %s", admin.Role)
+	}
+}
+
+func TestSetUserActive(t *testing.T) {
+	db := newTestDB(t, &models.User{}, &models.RefreshToken{})
+	service := NewUserAssignmentService(db, NewPasswordHasher(testArgon2))
+	schoolID := uuid.New()
+	teacher := createStaff(t, db, &schoolID, "teacher")
+	colleague := createStaff(t, db, &schoolID, "teacher")
+	session := createRefreshToken(t, db, teacher.ID)
+	otherSession := createRefreshToken(t, db, colleague.ID)
+
+	if err := service.SetUserActive(schoolID, teacher.ID, false); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var stored models.User
+	db.First(&stored, "id = ?", teacher.ID)
+	if stored.IsActive {
+		t.Error("Expected the teacher deactivated")
+	}
+	if !revoked(t, db, session) {
+		t.Error("Expected the teacher's refresh token revoked")
+	}
+	if revoked(t, db, otherSession) {
+		t.Error("Expected a colleague's refresh token left alone")
+	}
+
+	// Reactivating does not bring old sessions back
+	if err := service.SetUserActive(schoolID, teacher.ID, true); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	db.First(&stored, "id = ?", teacher.ID)
+	if !stored.IsActive || !revoked(t, db, session) {
+		t.Errorf("Expected the teacher active with the token still revoked, got active %v", stored.IsActive)
+	}
+
+	systemAdmin := createStaff(t, db, &schoolID, "system_admin")
+	adminSession := createRefreshToken(t, db, systemAdmin.ID)
+	if err := service.SetUserActive(schoolID, systemAdmin.ID, false); !errors.Is(err, ErrUserNotInSchool) {
+		t.Errorf("Expected ErrUserNotInSchool for a system admin, got %v", err)
+	}
+	if revoked(t, db, adminSession) {
+		t.Error("Expected the system admin's refresh token left alone")
+	}
+	if err := service.SetUserActive(uuid.New(), teacher.ID, false); !errors.Is(err, ErrUserNotInSchool) {
+		t.Errorf("Expected ErrUserNotInSchool from another school, got %v", err)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	db := newTestDB(t, &models.User{}, &models.RefreshToken{})
+	hasher := NewPasswordHasher(testArgon2)
+	service := NewUserAssignmentService(db, hasher)
+	schoolID := uuid.New()
+	teacher := createStaff(t, db, &schoolID, "teacher")
+	session := createRefreshToken(t, db, teacher.ID)
+
+	password, err := service.ResetPassword(schoolID, teacher.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	var stored models.User
+	db.First(&stored, "id = ?", teacher.ID)
+	if match, _, err := hasher.Verify(stored.PasswordHash, password); err != nil || !match {
+		t.Errorf("Expected the new password stored, got match %v (%v)", match, err)
+	}
+	if !stored.MustChangePassword {
+		t.Error("Expected the teacher to change the password at next login")
+	}
+	if !revoked(t, db, session) {
+		t.Error("Expected existing sessions revoked")
+	}
+
+	systemAdmin := createStaff(t, db, &schoolID, "system_admin")
+	if _, err := service.ResetPassword(schoolID, systemAdmin.ID); !errors.Is(err, ErrUserNotInSchool) {
+		t.Errorf("Expected ErrUserNotInSchool for a system admin, got %v", err)
+	}
+}
@@ -0,0 +1,250 @@
+// Package spreadsheet reads CSV and XLSX uploads into rows of strings without
+// external dependencies. Only the first worksheet of a workbook is read.
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupportedFormat is returned for files that are neither .csv nor .xlsx
+var ErrUnsupportedFormat = errors.New("unsupported file format: upload a .csv or .xlsx file")
+
+// ErrFileTooLarge is returned for files, or workbook parts once decompressed,
+// over the size limits
+var ErrFileTooLarge = errors.New("file is too large")
+
+const (
+	// MaxFileSize is the largest file Read accepts
+	MaxFileSize = 10 << 20
+	// maxEntrySize caps each workbook part once decompressed
+	maxEntrySize = 50 << 20
+	// Excel's own sheet limits; references beyond them are rejected rather
+	// than padded out to
+	maxRows    = 1048576
+	maxColumns = 16384
+)
+
+// Read parses a CSV or XLSX file, chosen by the filename's extension. Trailing
+// empty rows are dropped and cells are trimmed of surrounding whitespace.
+func Read(filename string, r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(data) > MaxFileSize {
+		return nil, ErrFileTooLarge
+	}
+
+	var rows [][]string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		rows, err = readCSV(data)
+	case ".xlsx":
+		rows, err = readXLSX(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range rows {
+		for j := range rows[i] {
+			rows[i][j] = strings.TrimSpace(rows[i][j])
+		}
+	}
+	for len(rows) > 0 && isBlank(rows[len(rows)-1]) {
+		rows = rows[:len(rows)-1]
+	}
+	return rows, nil
+}
+
+func isBlank(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func readCSV(data []byte) ([][]string, error) {
+	// Excel prefixes UTF-8 CSV exports with a byte order mark
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	return rows, nil
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxRichText struct {
+	Text string        `xml:"t"`
+	Runs []xlsxTextRun `xml:"r"`
+}
+
+type xlsxTextRun struct {
+	Text string `xml:"t"`
+}
+
+func (t xlsxRichText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var sb strings.Builder
+	for _, run := range t.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Index int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid XLSX: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	var sheets []string
+	for _, f := range archive.File {
+		files[f.Name] = f
+		if strings.HasPrefix(f.Name, "xl/worksheets/") && strings.HasSuffix(f.Name, ".xml") {
+			sheets = append(sheets, f.Name)
+		}
+	}
+	if len(sheets) == 0 {
+		return nil, errors.New("invalid XLSX: workbook has no worksheets")
+	}
+	sheetName := "xl/worksheets/sheet1.xml"
+	if files[sheetName] == nil {
+		sort.Strings(sheets)
+		sheetName = sheets[0]
+	}
+
+	var shared xlsxSharedStrings
+	if f := files["xl/sharedStrings.xml"]; f != nil {
+		if err := decodeXML(f, &shared); err != nil {
+			return nil, fmt.Errorf("invalid XLSX shared strings: %w", err)
+		}
+	}
+
+	var sheet xlsxWorksheet
+	if err := decodeXML(files[sheetName], &sheet); err != nil {
+		return nil, fmt.Errorf("invalid XLSX worksheet: %w", err)
+	}
+
+	var rows [][]string
+	for _, row := range sheet.Rows {
+		// Rows and cells may be sparse; place them by their references
+		index := len(rows)
+		if row.Index > 0 {
+			index = row.Index - 1
+		}
+		if index >= maxRows {
+			return nil, fmt.Errorf("invalid XLSX: row %d is beyond the sheet limit of %d rows", index+1, maxRows)
+		}
+		for len(rows) <= index {
+			rows = append(rows, nil)
+		}
+
+		var cells []string
+		for _, cell := range row.Cells {
+			col := len(cells)
+			if cell.Ref != "" {
+				if c, ok := columnIndex(cell.Ref); ok {
+					col = c
+				}
+			}
+			if col >= maxColumns {
+				return nil, fmt.Errorf("invalid XLSX: cell %s is beyond the sheet limit of %d columns", cell.Ref, maxColumns)
+			}
+			for len(cells) <= col {
+				cells = append(cells, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				i, err := strconv.Atoi(cell.Value)
+				if err != nil || i < 0 || i >= len(shared.Items) {
+					return nil, fmt.Errorf("invalid XLSX: bad shared string reference in %s", cell.Ref)
+				}
+				cells[col] = shared.Items[i].String()
+			case "inlineStr":
+				cells[col] = cell.Inline.String()
+			default:
+				cells[col] = cell.Value
+			}
+		}
+		rows[index] = cells
+	}
+	return rows, nil
+}
+
+// decodeXML decodes a workbook part, refusing to decompress more than
+// maxEntrySize of it
+func decodeXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	limited := &io.LimitedReader{R: rc, N: maxEntrySize}
+	if err := xml.NewDecoder(limited).Decode(v); err != nil {
+		if limited.N <= 0 {
+			return ErrFileTooLarge
+		}
+		return err
+	}
+	return nil
+}
+
+// columnIndex converts the letters of a cell reference such as "AB12" to a
+// zero-based column index. The index stops growing once it passes maxColumns,
+// so overlong references cannot overflow.
+func columnIndex(ref string) (int, bool) {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		if col <= maxColumns {
+			col = col*26 + int(r-'A'+1)
+		}
+		n++
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return col - 1, true
+}
@@ -0,0 +1,158 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	input := "\xef\xbb\xbfFirst Name,Last Name\n Jane ,Doe\nJohn,Okello,extra\n,\n"
+	rows, err := Read("students.CSV", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := [][]string{
+		{"First Name", "Last Name"},
+		{"Jane", "Doe"},
+		{"John", "Okello", "extra"},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected %v, got %v", expected, rows)
+	}
+}
+
+func TestReadXLSX(t *testing.T) {
+	buf := buildXLSX(t, map[string]string{
+		"xl/sharedStrings.xml": `<sst><si><t>Name</t></si><si><r><t>Ja</t></r><r><t>ne</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="inlineStr"><is><t>Mark</t></is></c></row>` +
+			`<row r="3"><c r="A3" t="s"><v>1</v></c><c r="C3"><v>85.5</v></c></row>` +
+			`</sheetData></worksheet>`,
+	})
+
+	rows, err := Read("marks.xlsx", buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := [][]string{
+		{"Name", "", "Mark"},
+		nil,
+		{"Jane", "", "85.5"},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected %q, got %q", expected, rows)
+	}
+}
+
+func buildXLSX(t *testing.T, files map[string]string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func TestReadXLSX_OutOfRangeReferences(t *testing.T) {
+	sheets := map[string]string{
+		"row":    `<row r="2000000000"><c r="A2000000000"><v>1</v></c></row>`,
+		"column": `<row r="1"><c r="ZZZZZZZ1"><v>1</v></c></row>`,
+		"long":   `<row r="1"><c r="` + strings.Repeat("Z", 40) + `1"><v>1</v></c></row>`,
+	}
+	for name, rows := range sheets {
+		buf := buildXLSX(t, map[string]string{
+			"xl/worksheets/sheet1.xml": `<worksheet><sheetData>` + rows + `</sheetData></worksheet>`,
+		})
+		if _, err := Read("marks.xlsx", buf); err == nil || !strings.Contains(err.Error(), "beyond the sheet limit") {
+			t.Errorf("%s: expected a sheet limit error, got %v", name, err)
+		}
+	}
+}
+
+func TestReadXLSX_DecompressionLimit(t *testing.T) {
+	// A few hundred KB that inflates past maxEntrySize
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte(`<worksheet><sheetData>`))
+	padding := bytes.Repeat([]byte(" "), 1<<20)
+	for written := 0; written <= maxEntrySize; written += len(padding) {
+		w.Write(padding)
+	}
+	w.Write([]byte(`</sheetData></worksheet>`))
+	zw.Close()
+
+	if _, err := Read("marks.xlsx", &buf); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("Expected ErrFileTooLarge, got %v", err)
+	}
+}
+
+func TestReadTooLarge(t *testing.T) {
+	input := strings.NewReader(strings.Repeat("a,b\n", MaxFileSize/4+1))
+	if _, err := Read("students.csv", input); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("Expected ErrFileTooLarge, got %v", err)
+	}
+}
+
+func TestReadUnsupported(t *testing.T) {
+	if _, err := Read("students.xls", strings.NewReader("")); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	tests := map[string]int{"A1": 0, "C7": 2, "Z2": 25, "AA10": 26, "AB3": 27, "XFD1": 16383}
+	for ref, expected := range tests {
+		if got, ok := columnIndex(ref); !ok || got != expected {
+			t.Errorf("columnIndex(%q) = %d, expected %d", ref, got, expected)
+		}
+	}
+}
+
+func TestWriteXLSXRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"Admission No", "Name", "Marks"},
+		{"007", "Okello & Sons <Ltd>", "85.5"},
+		{"NSS/S1/2025/002", "Jane", ""},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, FormatXLSX, "S1 [Term 1]", rows); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got, err := Read("sheet.xlsx", &buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := [][]string{rows[0], rows[1], {"NSS/S1/2025/002", "Jane"}}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for index, expected := range tests {
+		if got := columnName(index); got != expected {
+			t.Errorf("columnName(%d) = %q, expected %q", index, got, expected)
+		}
+	}
+}